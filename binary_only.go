@@ -0,0 +1,98 @@
+package pipinstall
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+// noDistributionPattern matches the messages with which pip reports a
+// requirement for which it found no distribution it is allowed to install.
+var noDistributionPattern = regexp.MustCompile(`(?m)(?:No matching distribution found for|Could not find a version that satisfies the requirement) (\S+)`)
+
+// binaryOnlyPolicy reports whether building distributions from source is
+// forbidden through BP_PIP_BINARY_ONLY, and returns the normalized names of
+// the projects that are still allowed to be built from their sdist, given in
+// BP_PIP_ALLOW_SDIST as a comma-separated list.
+func binaryOnlyPolicy() (bool, []string, error) {
+	value, exists := os.LookupEnv("BP_PIP_BINARY_ONLY")
+	if !exists {
+		return false, nil, nil
+	}
+
+	binaryOnly, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to parse BP_PIP_BINARY_ONLY value '%s': %w", value, err)
+	}
+
+	if !binaryOnly {
+		return false, nil, nil
+	}
+
+	var allowed []string
+	for _, name := range strings.Split(os.Getenv("BP_PIP_ALLOW_SDIST"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed = append(allowed, NormalizeName(name))
+		}
+	}
+
+	return true, allowed, nil
+}
+
+// formatArgs returns the pip options that forbid installing from sdists,
+// except for the allowed projects. As pip cannot allow both formats for a
+// project while only binaries are allowed for all others, the allowed
+// projects are installed from their sdist, which pip builds itself.
+func formatArgs(binaryOnly bool, allowed []string) []string {
+	if !binaryOnly {
+		return nil
+	}
+
+	args := []string{"--only-binary=:all:"}
+	if len(allowed) > 0 {
+		args = append(args, fmt.Sprintf("--no-binary=%s", strings.Join(allowed, ",")))
+	}
+
+	return args
+}
+
+// sdistAllowed reports whether the project may be built from its sdist
+// under the binary-only policy.
+func sdistAllowed(name string, binaryOnly bool, allowed []string) bool {
+	if !binaryOnly {
+		return true
+	}
+
+	for _, candidate := range allowed {
+		if NormalizeName(name) == candidate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// missingWheels returns the names of the projects that pip reported to have
+// no installable distribution in its output.
+func missingWheels(output string) []string {
+	var names []string
+	seen := map[string]bool{}
+
+	for _, match := range noDistributionPattern.FindAllStringSubmatch(output, -1) {
+		name := match[1]
+		if requirement, err := pep508.ParseRequirement(match[1]); err == nil {
+			name = requirement.Name
+		}
+
+		if !seen[NormalizeName(name)] {
+			seen[NormalizeName(name)] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -1,7 +1,11 @@
 package pipinstall
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/paketo-buildpacks/packit/v2"
@@ -10,11 +14,16 @@ import (
 	"github.com/paketo-buildpacks/packit/v2/fs"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 )
 
 //go:generate faux --interface EntryResolver --output fakes/entry_resolver.go
 //go:generate faux --interface InstallProcess --output fakes/install_process.go
 //go:generate faux --interface SitePackagesProcess --output fakes/site_packages_process.go
+//go:generate faux --interface BytecodeProcess --output fakes/bytecode_process.go
+//go:generate faux --interface CacheProcess --output fakes/cache_process.go
+//go:generate faux --interface BindingResolver --output fakes/binding_resolver.go
+//go:generate faux --interface VenvProcess --output fakes/venv_process.go
 //go:generate faux --interface SBOMGenerator --output fakes/sbom_generator.go
 
 // EntryResolver defines the interface for picking the most relevant entry from
@@ -25,7 +34,7 @@ type EntryResolver interface {
 
 // InstallProcess defines the interface for installing the pip dependencies.
 type InstallProcess interface {
-	Execute(workingDir, targetDir, cacheDir string) error
+	Execute(ctx context.Context, workingDir, targetDir, cacheDir string, packages, env []string) (InstallResult, error)
 }
 
 // SitePackagesProcess defines the interface for determining the site-packages path.
@@ -33,6 +42,29 @@ type SitePackagesProcess interface {
 	Execute(layerPath string) (sitePackagesPath string, err error)
 }
 
+// BytecodeProcess defines the interface for byte-compiling the installed
+// packages.
+type BytecodeProcess interface {
+	Execute(sitePackagesPath string) error
+}
+
+// CacheProcess defines the interface for bounding the size of the pip cache.
+type CacheProcess interface {
+	Execute(cacheDir string) (CacheUsage, error)
+}
+
+// VenvProcess defines the interface for creating the virtual environment
+// the packages are installed into.
+type VenvProcess interface {
+	Execute(path string) error
+}
+
+// BindingResolver defines the interface for resolving the service bindings
+// of the build.
+type BindingResolver interface {
+	Resolve(typ, provider, platformDir string) ([]servicebindings.Binding, error)
+}
+
 type SBOMGenerator interface {
 	Generate(dir string) (sbom.SBOM, error)
 }
@@ -42,11 +74,42 @@ type SBOMGenerator interface {
 //
 // Build will install the pip dependencies by using the requirements.txt file
 // to a packages layer. It also makes use of a cache layer to reuse the pip
-// cache.
-func Build(installProcess InstallProcess, siteProcess SitePackagesProcess, sbomGenerator SBOMGenerator, clock chronos.Clock, logger scribe.Emitter) packit.BuildFunc {
+// cache. The layout of the packages layer, the environment it sets and the
+// processes it contributes are configured through the environment variables
+// described in the README.
+func Build(installProcess InstallProcess, venvProcess VenvProcess, siteProcess SitePackagesProcess, bytecodeProcess BytecodeProcess, cacheProcess CacheProcess, bindingResolver BindingResolver, sbomGenerator SBOMGenerator, clock chronos.Clock, logger scribe.Emitter) packit.BuildFunc {
 	return func(context packit.BuildContext) (packit.BuildResult, error) {
 		logger.Title("%s %s", context.BuildpackInfo.Name, context.BuildpackInfo.Version)
 
+		mode, err := compileMode()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		timeout, err := installTimeout()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		envMode, err := envMode()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		layout, err := installLayout()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if layout != "user" && envMode == "userbase" {
+			return packit.BuildResult{}, fmt.Errorf("BP_PIP_ENV_MODE=userbase requires the 'user' install layout, but BP_PIP_INSTALL_LAYOUT is '%s'", layout)
+		}
+
+		script, args, err := defaultProcess()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
 		packagesLayer, err := context.Layers.Get(PackagesLayerName)
 		if err != nil {
 			return packit.BuildResult{}, err
@@ -57,10 +120,63 @@ func Build(installProcess InstallProcess, siteProcess SitePackagesProcess, sbomG
 			return packit.BuildResult{}, err
 		}
 
+		packages, err := planPackages(context.Plan.Entries)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		bindings, err := caBindings(bindingResolver, context.Platform.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
 		logger.Process("Executing build process")
+		if timeout > 0 {
+			logger.Subprocess("Timeout: %s", timeout)
+		}
+
+		var env []string
+		if len(bindings) > 0 {
+			bundleDir, err := os.MkdirTemp("", "ca-certificates")
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			defer os.RemoveAll(bundleDir)
+
+			bundle := filepath.Join(bundleDir, "ca-bundle.crt")
+			count, err := writeCABundle(bundle, bindings)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			var names []string
+			for _, binding := range bindings {
+				names = append(names, binding.Name)
+			}
+
+			if count == 0 {
+				logger.Subprocess("Warning: service bindings '%s' contain no PEM certificates", strings.Join(names, "', '"))
+			} else {
+				logger.Subprocess("Trusting %d CA certificates from service bindings '%s'", count, strings.Join(names, "', '"))
+				env = append(env, fmt.Sprintf("PIP_CERT=%s", bundle), fmt.Sprintf("REQUESTS_CA_BUNDLE=%s", bundle), fmt.Sprintf("SSL_CERT_FILE=%s", bundle))
+			}
+		}
+
+		if layout == "venv" {
+			logger.Subprocess("Creating virtual environment at %s", packagesLayer.Path)
+			err = venvProcess.Execute(packagesLayer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		var install InstallResult
+		ctx, stop := installContext(timeout)
 		duration, err := clock.Measure(func() error {
-			return installProcess.Execute(context.WorkingDir, packagesLayer.Path, cacheLayer.Path)
+			install, err = installProcess.Execute(ctx, context.WorkingDir, packagesLayer.Path, cacheLayer.Path, packages, env)
+			return err
 		})
+		stop()
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
@@ -68,38 +184,160 @@ func Build(installProcess InstallProcess, siteProcess SitePackagesProcess, sbomG
 		logger.Action("Completed in %s", duration.Round(time.Millisecond))
 		logger.Break()
 
+		usage, err := cacheProcess.Execute(cacheLayer.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if cacheLayer.Metadata == nil {
+			cacheLayer.Metadata = map[string]interface{}{}
+		}
+		delete(cacheLayer.Metadata, "cache_size_limit")
+		delete(cacheLayer.Metadata, "cache_size_before")
+		delete(cacheLayer.Metadata, "cache_size_after")
+		if usage.Limit > 0 {
+			logger.Process("Enforcing pip cache size limit of %s", formatSize(usage.Limit))
+			logger.Subprocess("Cache size before: %s", formatSize(usage.Before))
+			logger.Subprocess("Cache size after: %s (%d entries evicted)", formatSize(usage.After), usage.Evicted)
+			logger.Break()
+
+			cacheLayer.Metadata["cache_size_limit"] = usage.Limit
+			cacheLayer.Metadata["cache_size_before"] = usage.Before
+			cacheLayer.Metadata["cache_size_after"] = usage.After
+		}
+
+		if packagesLayer.Metadata == nil {
+			packagesLayer.Metadata = map[string]interface{}{}
+		}
+		delete(packagesLayer.Metadata, "index_mirror")
+		if install.IndexMirror != "" {
+			packagesLayer.Metadata["index_mirror"] = redactCredentials(install.IndexMirror)
+		}
+
+		delete(packagesLayer.Metadata, "requested_packages")
+		if len(packages) > 0 {
+			packagesLayer.Metadata["requested_packages"] = packages
+		}
+
 		planner := draft.NewPlanner()
 
 		packagesLayer.Launch, packagesLayer.Build = planner.MergeLayerTypes(SitePackages, context.Plan.Entries)
 		packagesLayer.Cache = packagesLayer.Launch || packagesLayer.Build
 		cacheLayer.Cache = true
 
+		summary := buildSummary{install: install}
+
 		sitePackagesPath, err := siteProcess.Execute(packagesLayer.Path)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		logger.GeneratingSBOM(packagesLayer.Path)
+		if _, err := os.Stat(sitePackagesPath); err == nil && mode == "parallel" {
+			logger.Process("Compiling bytecode")
+			duration, err = clock.Measure(func() error {
+				return bytecodeProcess.Execute(sitePackagesPath)
+			})
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			summary.compiled = true
+			summary.compile = duration
+
+			logger.Action("Completed in %s", duration.Round(time.Millisecond))
+			logger.Break()
+		}
+
+		// The layer directory only exists if pip installed anything into it.
+		err = os.MkdirAll(packagesLayer.Path, os.ModePerm)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		logger.Process("Writing package inventory")
+		inventoryPath, count, err := writeInventory(packagesLayer.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+		logger.Subprocess("Listed %d packages in %s", count, inventoryPath)
+		logger.Break()
+
+		packagesLayer.BuildEnv.Default("BP_PIP_INSTALL_INVENTORY", inventoryPath)
+
+		var processes []packit.Process
+		if script != "" {
+			process, err := launchProcess(packagesLayer.Path, script, args)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			processes = append(processes, process)
+
+			// The process runs from the layer, which must then be available at launch.
+			packagesLayer.Launch = true
+			packagesLayer.Cache = true
+		}
+
+		// The distributions built from local projects and VCS requirements,
+		// and those requested by other buildpacks, are not described by the
+		// files of the app, so the SBOM is then generated from the
+		// distributions in the packages layer.
+		sbomDir := context.WorkingDir
+		if install.LocalProjects > 0 || install.VCSRequirements > 0 || len(packages) > 0 {
+			sbomDir = packagesLayer.Path
+		}
+
+		logger.GeneratingSBOM(sbomDir)
 
 		var sbomContent sbom.SBOM
 		duration, err = clock.Measure(func() error {
-			sbomContent, err = sbomGenerator.Generate(context.WorkingDir)
+			sbomContent, err = sbomGenerator.Generate(sbomDir)
 			return err
 		})
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
+		summary.sbom = duration
 		logger.Action("Completed in %s", duration.Round(time.Millisecond))
 		logger.Break()
 
 		logger.FormattingSBOM(context.BuildpackInfo.SBOMFormats...)
 
-		packagesLayer.SBOM, err = sbomContent.InFormats(context.BuildpackInfo.SBOMFormats...)
+		formatter, err := sbomContent.InFormats(context.BuildpackInfo.SBOMFormats...)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		packagesLayer.SharedEnv.Prepend("PYTHONPATH", sitePackagesPath, string(os.PathListSeparator))
+		packagesLayer.SBOM = formatter
+		if len(packages) > 0 {
+			packagesLayer.SBOM = newRequestedSBOM(formatter, packages)
+		}
+
+		switch {
+		case layout == "venv":
+			packagesLayer.SharedEnv.Override("VIRTUAL_ENV", packagesLayer.Path)
+			packagesLayer.SharedEnv.Prepend("PATH", filepath.Join(packagesLayer.Path, "bin"), string(os.PathListSeparator))
+		case envMode == "userbase":
+			packagesLayer.SharedEnv.Override("PYTHONUSERBASE", packagesLayer.Path)
+		default:
+			packagesLayer.SharedEnv.Prepend("PYTHONPATH", sitePackagesPath, string(os.PathListSeparator))
+			if layout == "target" {
+				packagesLayer.SharedEnv.Prepend("PATH", filepath.Join(packagesLayer.Path, "bin"), string(os.PathListSeparator))
+			}
+
+			pths, err := pthFiles(sitePackagesPath)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if len(pths) > 0 {
+				logger.Process("Warning: site-packages contains .pth files, which are not processed when packages are found through PYTHONPATH: %s", strings.Join(pths, ", "))
+				if layout == "target" {
+					logger.Subprocess("Set BP_PIP_INSTALL_LAYOUT=venv to have them processed")
+				} else {
+					logger.Subprocess("Set BP_PIP_ENV_MODE=userbase to have them processed")
+				}
+				logger.Break()
+			}
+		}
 
 		logger.EnvironmentVariables(packagesLayer)
 
@@ -114,6 +352,23 @@ func Build(installProcess InstallProcess, siteProcess SitePackagesProcess, sbomG
 			Layers: layers,
 		}
 
+		if len(processes) > 0 {
+			logger.LaunchProcesses(processes)
+			result.Launch.Processes = processes
+		}
+
+		summary.packagesSize, err = dirSize(packagesLayer.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		summary.cacheSize, err = dirSize(cacheLayer.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		summary.log(logger)
+
 		return result, nil
 	}
 }
@@ -2,20 +2,27 @@ package pipinstall_test
 
 import (
 	"bytes"
+	gocontext "context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 	pipinstall "github.com/paketo-buildpacks/pip-install"
 	"github.com/paketo-buildpacks/pip-install/fakes"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
+	. "github.com/paketo-buildpacks/occam/matchers"
 )
 
 func testBuild(t *testing.T, context spec.G, it spec.S) {
@@ -27,7 +34,11 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		cnbDir     string
 
 		installProcess      *fakes.InstallProcess
+		venvProcess         *fakes.VenvProcess
 		sitePackagesProcess *fakes.SitePackagesProcess
+		bytecodeProcess     *fakes.BytecodeProcess
+		cacheProcess        *fakes.CacheProcess
+		bindingResolver     *fakes.BindingResolver
 		sbomGenerator       *fakes.SBOMGenerator
 
 		buffer *bytes.Buffer
@@ -42,9 +53,14 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		cnbDir = t.TempDir()
 
 		installProcess = &fakes.InstallProcess{}
+		venvProcess = &fakes.VenvProcess{}
 		sitePackagesProcess = &fakes.SitePackagesProcess{}
 		sitePackagesProcess.ExecuteCall.Returns.SitePackagesPath = "some-site-packages-path"
 
+		bytecodeProcess = &fakes.BytecodeProcess{}
+		cacheProcess = &fakes.CacheProcess{}
+		bindingResolver = &fakes.BindingResolver{}
+
 		sbomGenerator = &fakes.SBOMGenerator{}
 		sbomGenerator.GenerateCall.Returns.SBOM = sbom.SBOM{}
 
@@ -52,7 +68,11 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 		build = pipinstall.Build(
 			installProcess,
+			venvProcess,
 			sitePackagesProcess,
+			bytecodeProcess,
+			cacheProcess,
+			bindingResolver,
 			sbomGenerator,
 			chronos.DefaultClock,
 			scribe.NewEmitter(buffer),
@@ -95,7 +115,9 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(packagesLayer.Launch).To(BeFalse())
 		Expect(packagesLayer.Cache).To(BeFalse())
 
-		Expect(packagesLayer.BuildEnv).To(BeEmpty())
+		Expect(packagesLayer.BuildEnv).To(Equal(packit.Environment{
+			"BP_PIP_INSTALL_INVENTORY.default": filepath.Join(layersDir, "packages", "inventory.json"),
+		}))
 		Expect(packagesLayer.LaunchEnv).To(BeEmpty())
 		Expect(packagesLayer.ProcessLaunchEnv).To(BeEmpty())
 
@@ -103,6 +125,8 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(packagesLayer.SharedEnv["PYTHONPATH.prepend"]).To(Equal("some-site-packages-path"))
 		Expect(packagesLayer.SharedEnv["PYTHONPATH.delim"]).To(Equal(":"))
 
+		Expect(venvProcess.ExecuteCall.CallCount).To(Equal(0))
+
 		Expect(packagesLayer.SBOM.Formats()).To(HaveLen(2))
 		var actualExtensions []string
 		for _, format := range packagesLayer.SBOM.Formats() {
@@ -116,12 +140,43 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 		Expect(sitePackagesProcess.ExecuteCall.Receives.LayerPath).To(Equal(filepath.Join(layersDir, "packages")))
 
+		Expect(bytecodeProcess.ExecuteCall.CallCount).To(Equal(0))
+
+		Expect(cacheProcess.ExecuteCall.Receives.CacheDir).To(Equal(filepath.Join(layersDir, "cache")))
+		Expect(buffer.String()).NotTo(ContainSubstring("pip cache size limit"))
+
 		Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
 		Expect(buffer.String()).To(ContainSubstring("Executing build process"))
 
 		Expect(sbomGenerator.GenerateCall.Receives.Dir).To(Equal(workingDir))
 	})
 
+	context("when local projects are installed", func() {
+		it.Before(func() {
+			installProcess.ExecuteCall.Returns.InstallResult = pipinstall.InstallResult{LocalProjects: 1}
+		})
+
+		it("generates the SBOM from the packages layer", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sbomGenerator.GenerateCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "packages")))
+		})
+	})
+
+	context("when VCS requirements are installed", func() {
+		it.Before(func() {
+			installProcess.ExecuteCall.Returns.InstallResult = pipinstall.InstallResult{VCSRequirements: 1}
+		})
+
+		it("generates the SBOM from the packages layer", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sbomGenerator.GenerateCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "packages")))
+		})
+	})
+
 	context("site-packages required at build and launch", func() {
 		it.Before(func() {
 			buildContext.Plan.Entries[0].Metadata["launch"] = true
@@ -164,11 +219,657 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("when packages are installed into site-packages", func() {
+		var sitePackagesPath string
+
+		it.Before(func() {
+			sitePackagesPath = filepath.Join(layersDir, "packages", "lib", "python3.12", "site-packages")
+			sitePackagesProcess.ExecuteCall.Returns.SitePackagesPath = sitePackagesPath
+
+			installProcess.ExecuteCall.Stub = func(_ gocontext.Context, _, _, _ string, _, _ []string) (pipinstall.InstallResult, error) {
+				return pipinstall.InstallResult{}, os.MkdirAll(sitePackagesPath, os.ModePerm)
+			}
+		})
+
+		it("byte-compiles them in parallel and reports the duration separately", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bytecodeProcess.ExecuteCall.CallCount).To(Equal(1))
+			Expect(bytecodeProcess.ExecuteCall.Receives.SitePackagesPath).To(Equal(sitePackagesPath))
+
+			Expect(buffer.String()).To(MatchRegexp(`Executing build process\n\s+Completed in \S+\n\n  Compiling bytecode\n\s+Completed in \S+\n`))
+		})
+
+		context("when site-packages contains .pth files", func() {
+			it.Before(func() {
+				installProcess.ExecuteCall.Stub = func(_ gocontext.Context, _, _, _ string, _, _ []string) (pipinstall.InstallResult, error) {
+					Expect(os.MkdirAll(sitePackagesPath, os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(sitePackagesPath, "distutils-precedence.pth"), nil, 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(sitePackagesPath, "ddtrace.pth"), nil, 0600)).To(Succeed())
+					return pipinstall.InstallResult{}, nil
+				}
+			})
+
+			it("warns that PYTHONPATH does not process them", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Warning: site-packages contains .pth files, which are not processed when packages are found through PYTHONPATH: ddtrace.pth, distutils-precedence.pth"))
+				Expect(buffer.String()).To(ContainSubstring("Set BP_PIP_ENV_MODE=userbase to have them processed"))
+			})
+
+			context("when BP_PIP_ENV_MODE is userbase", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_ENV_MODE", "userbase")
+				})
+
+				it("exposes the layer through PYTHONUSERBASE instead", func() {
+					result, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					packagesLayer := result.Layers[0]
+					Expect(packagesLayer.SharedEnv).To(Equal(packit.Environment{
+						"PYTHONUSERBASE.override": filepath.Join(layersDir, "packages"),
+					}))
+
+					Expect(buffer.String()).NotTo(ContainSubstring("Warning: site-packages contains .pth files"))
+				})
+			})
+		})
+
+		context("when BP_PIP_INSTALL_LAYOUT is venv", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_INSTALL_LAYOUT", "venv")
+			})
+
+			it("installs into a virtual environment in the packages layer and activates it", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(venvProcess.ExecuteCall.CallCount).To(Equal(1))
+				Expect(venvProcess.ExecuteCall.Receives.Path).To(Equal(filepath.Join(layersDir, "packages")))
+				Expect(installProcess.ExecuteCall.Receives.TargetDir).To(Equal(filepath.Join(layersDir, "packages")))
+
+				packagesLayer := result.Layers[0]
+				Expect(packagesLayer.SharedEnv).To(Equal(packit.Environment{
+					"VIRTUAL_ENV.override": filepath.Join(layersDir, "packages"),
+					"PATH.prepend":         filepath.Join(layersDir, "packages", "bin"),
+					"PATH.delim":           ":",
+				}))
+
+				Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Creating virtual environment at %s", filepath.Join(layersDir, "packages"))))
+			})
+
+			context("when the virtual environment cannot be created", func() {
+				it.Before(func() {
+					venvProcess.ExecuteCall.Returns.Error = errors.New("could not create virtual environment")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("could not create virtual environment"))
+					Expect(installProcess.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		context("when BP_PIP_INSTALL_LAYOUT is target", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_INSTALL_LAYOUT", "target")
+			})
+
+			it("prepends the site-packages and bin directories of the layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(venvProcess.ExecuteCall.CallCount).To(Equal(0))
+
+				packagesLayer := result.Layers[0]
+				Expect(packagesLayer.SharedEnv).To(Equal(packit.Environment{
+					"PYTHONPATH.prepend": sitePackagesPath,
+					"PYTHONPATH.delim":   ":",
+					"PATH.prepend":       filepath.Join(layersDir, "packages", "bin"),
+					"PATH.delim":         ":",
+				}))
+			})
+		})
+
+		context("when BP_PIP_COMPILE is serial or off", func() {
+			it("does not byte-compile them", func() {
+				for _, mode := range []string{"serial", "off"} {
+					t.Setenv("BP_PIP_COMPILE", mode)
+
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				Expect(bytecodeProcess.ExecuteCall.CallCount).To(Equal(0))
+				Expect(buffer.String()).NotTo(ContainSubstring("Compiling bytecode"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when byte-compiling fails", func() {
+				it.Before(func() {
+					bytecodeProcess.ExecuteCall.Returns.Error = errors.New("could not compile")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("could not compile"))
+				})
+			})
+
+			context("when BP_PIP_ENV_MODE is userbase with another install layout", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_ENV_MODE", "userbase")
+					t.Setenv("BP_PIP_INSTALL_LAYOUT", "venv")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("BP_PIP_ENV_MODE=userbase requires the 'user' install layout, but BP_PIP_INSTALL_LAYOUT is 'venv'"))
+				})
+			})
+
+			context("when BP_PIP_DEFAULT_PROCESS is blank", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_DEFAULT_PROCESS", "  ")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("invalid BP_PIP_DEFAULT_PROCESS value '  ': must name a console script"))
+					Expect(installProcess.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when BP_PIP_DEFAULT_PROCESS is a path", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_DEFAULT_PROCESS", "/usr/bin/gunicorn app:app")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("invalid BP_PIP_DEFAULT_PROCESS value '/usr/bin/gunicorn app:app': '/usr/bin/gunicorn' is not the name of a console script"))
+				})
+			})
+
+			context("when BP_PIP_INSTALL_LAYOUT is invalid", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_INSTALL_LAYOUT", "system")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring("invalid BP_PIP_INSTALL_LAYOUT value 'system'")))
+					Expect(installProcess.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when BP_PIP_ENV_MODE is invalid", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_ENV_MODE", "path")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("invalid BP_PIP_ENV_MODE value 'path': must be one of 'pythonpath' or 'userbase'"))
+					Expect(installProcess.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when BP_PIP_COMPILE is invalid", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_COMPILE", "sometimes")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring("invalid BP_PIP_COMPILE value 'sometimes'")))
+					Expect(installProcess.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+	})
+
+	context("when a service binding provides CA certificates", func() {
+		var (
+			systemBundle string
+			bindingDir   string
+			environment  map[string]string
+		)
+
+		it.Before(func() {
+			systemBundle = filepath.Join(t.TempDir(), "ca-certificates.crt")
+			Expect(os.WriteFile(systemBundle, []byte("-----BEGIN CERTIFICATE-----\nc3lzdGVt\n-----END CERTIFICATE-----"), 0600)).To(Succeed())
+			t.Setenv("SSL_CERT_FILE", systemBundle)
+			t.Setenv("PIP_CERT", "some-user-bundle")
+
+			bindingDir = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(bindingDir, "corporate.pem"), []byte("-----BEGIN CERTIFICATE-----\nY29ycG9yYXRl\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\naW50ZXJtZWRpYXRl\n-----END CERTIFICATE-----\n"), 0600)).To(Succeed())
+
+			bindingResolver.ResolveCall.Stub = func(typ, _, platformDir string) ([]servicebindings.Binding, error) {
+				Expect(platformDir).To(Equal("some-platform-path"))
+				if typ != "ca-certificates" {
+					return nil, nil
+				}
+
+				return []servicebindings.Binding{{
+					Name: "corporate-ca",
+					Type: "ca-certificates",
+					Path: bindingDir,
+					Entries: map[string]*servicebindings.Entry{
+						"type":          servicebindings.NewWithValue([]byte("ca-certificates")),
+						"corporate.pem": servicebindings.NewEntry(filepath.Join(bindingDir, "corporate.pem")),
+					},
+				}}, nil
+			}
+
+			environment = map[string]string{}
+			installProcess.ExecuteCall.Stub = func(_ gocontext.Context, _, _, _ string, _, env []string) (pipinstall.InstallResult, error) {
+				for _, variable := range env {
+					name, value, _ := strings.Cut(variable, "=")
+					environment[name] = value
+				}
+
+				content, _ := os.ReadFile(environment["PIP_CERT"])
+				environment["bundle"] = string(content)
+				return pipinstall.InstallResult{}, nil
+			}
+		})
+
+		it("gives pip a bundle of the system and bound certificates for the install only", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(environment["PIP_CERT"]).NotTo(BeEmpty())
+			Expect(environment["REQUESTS_CA_BUNDLE"]).To(Equal(environment["PIP_CERT"]))
+			Expect(environment["SSL_CERT_FILE"]).To(Equal(environment["PIP_CERT"]))
+			Expect(environment["bundle"]).To(Equal("-----BEGIN CERTIFICATE-----\nc3lzdGVt\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\nY29ycG9yYXRl\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\naW50ZXJtZWRpYXRl\n-----END CERTIFICATE-----\n"))
+
+			Expect(environment["PIP_CERT"]).NotTo(BeAnExistingFile())
+			Expect(os.Getenv("PIP_CERT")).To(Equal("some-user-bundle"))
+
+			for _, layer := range result.Layers {
+				Expect(layer.SharedEnv).NotTo(HaveKey(HavePrefix("PIP_CERT")))
+				Expect(layer.LaunchEnv).NotTo(HaveKey(HavePrefix("PIP_CERT")))
+			}
+
+			Expect(buffer.String()).To(ContainSubstring("Trusting 2 CA certificates from service bindings 'corporate-ca'"))
+		})
+
+		context("when the binding contains no certificates", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(bindingDir, "corporate.pem"), []byte("not a certificate"), 0600)).To(Succeed())
+			})
+
+			it("warns and leaves pip with the system certificates", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(environment).NotTo(HaveKey("PIP_CERT"))
+				Expect(buffer.String()).To(ContainSubstring("Warning: service bindings 'corporate-ca' contain no PEM certificates"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the bindings cannot be resolved", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Stub = nil
+					bindingResolver.ResolveCall.Returns.Error = errors.New("could not read bindings")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("failed to resolve 'ca-certificates' service bindings: could not read bindings"))
+					Expect(installProcess.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+	})
+
+	context("when packages are installed", func() {
+		it.Before(func() {
+			installProcess.ExecuteCall.Stub = func(_ gocontext.Context, _, targetDir, _ string, _, _ []string) (pipinstall.InstallResult, error) {
+				sitePackages := filepath.Join(targetDir, "lib", "python3.12", "site-packages")
+
+				distInfo := filepath.Join(sitePackages, "Flask-3.0.3.dist-info")
+				Expect(os.MkdirAll(distInfo, os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(distInfo, "METADATA"), []byte("Metadata-Version: 2.1\nName: Flask\nVersion: 3.0.3\n\nA description\n"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(distInfo, "entry_points.txt"), []byte("[console_scripts]\nflask = flask.cli:main\n\n[flask.commands]\nroutes = flask.cli:routes_command\n"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(distInfo, "RECORD"), []byte("flask/__init__.py,sha256=abc,123\nflask/cli.py,sha256=def,456\n../../../bin/flask,sha256=ghi,789\nFlask-3.0.3.dist-info/METADATA,,\n"), 0600)).To(Succeed())
+
+				distInfo = filepath.Join(sitePackages, "zope.interface-6.4.dist-info")
+				Expect(os.MkdirAll(distInfo, os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(distInfo, "METADATA"), []byte("Metadata-Version: 2.1\nName: zope.interface\nVersion: 6.4\n"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(distInfo, "top_level.txt"), []byte("zope\n"), 0600)).To(Succeed())
+
+				return pipinstall.InstallResult{}, nil
+			}
+		})
+
+		it("writes an inventory of them to the packages layer", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			content, err := os.ReadFile(filepath.Join(layersDir, "packages", "inventory.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(MatchJSON(`{
+				"version": 1,
+				"packages": [
+					{
+						"name": "Flask",
+						"version": "3.0.3",
+						"normalized_name": "flask",
+						"top_level_modules": ["flask"],
+						"console_scripts": ["flask"]
+					},
+					{
+						"name": "zope.interface",
+						"version": "6.4",
+						"normalized_name": "zope-interface",
+						"top_level_modules": ["zope"],
+						"console_scripts": []
+					}
+				]
+			}`))
+
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Listed 2 packages in %s", filepath.Join(layersDir, "packages", "inventory.json"))))
+		})
+
+		context("when BP_PIP_DEFAULT_PROCESS names an installed console script", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_DEFAULT_PROCESS", "flask run  --host 0.0.0.0")
+			})
+
+			it("contributes a default web process running it from the packages layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(Equal([]packit.Process{
+					{
+						Type:    "web",
+						Command: filepath.Join(layersDir, "packages", "bin", "flask"),
+						Args:    []string{"run", "--host", "0.0.0.0"},
+						Default: true,
+					},
+				}))
+
+				packagesLayer := result.Layers[0]
+				Expect(packagesLayer.Launch).To(BeTrue())
+				Expect(packagesLayer.Cache).To(BeTrue())
+
+				Expect(buffer.String()).To(ContainSubstring("Assigning launch processes:"))
+				Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("web (default): %s run --host 0.0.0.0", filepath.Join(layersDir, "packages", "bin", "flask"))))
+			})
+		})
+
+		context("when BP_PIP_DEFAULT_PROCESS names a script no package declares", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_DEFAULT_PROCESS", "gunicorn app:app")
+			})
+
+			it("returns an error listing the declared console scripts", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_PIP_DEFAULT_PROCESS names console script 'gunicorn', which none of the installed packages declare: choose one of 'flask'"))
+			})
+		})
+	})
+
+	context("when the install reports what it did", func() {
+		it.Before(func() {
+			installProcess.ExecuteCall.Stub = func(_ gocontext.Context, _, targetDir, cachePath string, _, _ []string) (pipinstall.InstallResult, error) {
+				Expect(os.MkdirAll(targetDir, os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(targetDir, "some-file"), make([]byte, 2048), 0600)).To(Succeed())
+				Expect(os.MkdirAll(cachePath, os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(cachePath, "some-file"), make([]byte, 512), 0600)).To(Succeed())
+
+				return pipinstall.InstallResult{
+					Direct:          3,
+					Transitive:      9,
+					Wheels:          10,
+					SourceBuilds:    2,
+					CacheHits:       1,
+					ResolveDuration: 1500 * time.Millisecond,
+					BuildDuration:   12 * time.Second,
+					InstallDuration: 250 * time.Millisecond,
+				}, nil
+			}
+		})
+
+		it("logs a summary of the build", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainLines(
+				"  Build summary",
+				"    Distributions     12 installed (3 direct, 9 transitive)",
+				"    Wheels            10",
+				"    Source builds     2",
+				"    Cache hits        1",
+				"    Packages layer    2.0 KiB",
+				"    Cache layer       512 B",
+				"    Resolve/download  1.5s",
+				"    Build wheels      12s",
+				"    Install           250ms",
+				"    Compile           -",
+				MatchRegexp(`^    SBOM              [0-9.]+[µnm]?s$`),
+			))
+		})
+	})
+
+	context("when BP_PIP_DEFAULT_PROCESS is set and no package declares console scripts", func() {
+		it.Before(func() {
+			t.Setenv("BP_PIP_DEFAULT_PROCESS", "gunicorn")
+		})
+
+		it("returns an error", func() {
+			_, err := build(buildContext)
+			Expect(err).To(MatchError("BP_PIP_DEFAULT_PROCESS names console script 'gunicorn', but none of the installed packages declare console scripts"))
+		})
+	})
+
+	context("when buildpacks request packages through the build plan", func() {
+		it.Before(func() {
+			buildContext.Plan.Entries = []packit.BuildpackPlanEntry{
+				{
+					Name:     "site-packages",
+					Metadata: map[string]interface{}{"launch": true, "packages": []interface{}{"gunicorn>=21", "uvicorn[standard]"}},
+				},
+				{
+					Name:     "site-packages",
+					Metadata: map[string]interface{}{"build": true, "packages": []interface{}{"gunicorn>=21", "gunicorn<23"}},
+				},
+				{
+					Name:     "site-packages",
+					Metadata: map[string]interface{}{},
+				},
+			}
+		})
+
+		it("merges them and installs them along with the app requirements", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(installProcess.ExecuteCall.Receives.Packages).To(Equal([]string{"gunicorn>=21", "uvicorn[standard]", "gunicorn<23"}))
+
+			Expect(result.Layers[0].Name).To(Equal("packages"))
+			Expect(result.Layers[0].Metadata).To(Equal(map[string]interface{}{
+				"requested_packages": []string{"gunicorn>=21", "uvicorn[standard]", "gunicorn<23"},
+			}))
+		})
+
+		it("generates the SBOM from the packages layer", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sbomGenerator.GenerateCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "packages")))
+		})
+
+		context("when the SBOM lists the requested packages", func() {
+			it.Before(func() {
+				installProcess.ExecuteCall.Stub = func(_ gocontext.Context, _, targetDir, _ string, _, _ []string) (pipinstall.InstallResult, error) {
+					for _, name := range []string{"gunicorn", "flask"} {
+						distInfo := filepath.Join(targetDir, "lib", "python3.12", "site-packages", fmt.Sprintf("%s-1.0.0.dist-info", name))
+						Expect(os.MkdirAll(distInfo, os.ModePerm)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(distInfo, "METADATA"), []byte(fmt.Sprintf("Metadata-Version: 2.1\nName: %s\nVersion: 1.0.0\n", name)), 0600)).To(Succeed())
+					}
+					return pipinstall.InstallResult{}, nil
+				}
+				sbomGenerator.GenerateCall.Stub = sbom.Generate
+			})
+
+			it("marks them in the CycloneDX and SPDX documents", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				documents := map[string]map[string]interface{}{}
+				for _, format := range result.Layers[0].SBOM.Formats() {
+					var document map[string]interface{}
+					Expect(json.NewDecoder(format.Content).Decode(&document)).To(Succeed())
+					documents[format.Extension] = document
+				}
+
+				marked := func(items interface{}, key, field string) []string {
+					var names []string
+					for _, item := range items.([]interface{}) {
+						entry := item.(map[string]interface{})
+						values, _ := entry[key].([]interface{})
+						for _, value := range values {
+							if value.(map[string]interface{})[field] == "paketo:pip-install:requested-by-buildpack" {
+								names = append(names, entry["name"].(string))
+							}
+						}
+					}
+					return names
+				}
+
+				Expect(marked(documents["cdx.json"]["components"], "properties", "name")).To(Equal([]string{"gunicorn"}))
+				Expect(marked(documents["spdx.json"]["packages"], "annotations", "comment")).To(Equal([]string{"gunicorn"}))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when a package is not a requirement specifier", func() {
+				it.Before(func() {
+					buildContext.Plan.Entries[0].Metadata["packages"] = []interface{}{"gunicorn>=>21"}
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring("invalid package 'gunicorn>=>21' requested through the build plan")))
+					Expect(installProcess.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when the packages are not a list", func() {
+				it.Before(func() {
+					buildContext.Plan.Entries[0].Metadata["packages"] = "gunicorn"
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("invalid build plan metadata 'packages' of 'site-packages': must be a list of requirement specifiers"))
+				})
+			})
+
+			context("when a package is a direct reference", func() {
+				it.Before(func() {
+					buildContext.Plan.Entries[0].Metadata["packages"] = []interface{}{"gunicorn @ https://example.com/gunicorn.whl"}
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("invalid package 'gunicorn @ https://example.com/gunicorn.whl' requested through the build plan: direct references are not supported"))
+				})
+			})
+		})
+	})
+
+	context("when the packages are installed from an index mirror", func() {
+		it.Before(func() {
+			installProcess.ExecuteCall.Returns.InstallResult = pipinstall.InstallResult{IndexMirror: "https://mirror.example.com/simple"}
+		})
+
+		it("records the mirror in the packages layer metadata", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[0].Name).To(Equal("packages"))
+			Expect(result.Layers[0].Metadata).To(Equal(map[string]interface{}{
+				"index_mirror": "https://mirror.example.com/simple",
+			}))
+		})
+	})
+
+	context("when BP_PIP_TIMEOUT is set", func() {
+		it.Before(func() {
+			t.Setenv("BP_PIP_TIMEOUT", "10m")
+		})
+
+		it("runs the install process in a context that is done once the timeout has passed", func() {
+			start := time.Now()
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			deadline, ok := installProcess.ExecuteCall.Receives.Ctx.Deadline()
+			Expect(ok).To(BeTrue())
+			Expect(deadline).To(BeTemporally("~", start.Add(10*time.Minute), time.Minute))
+
+			Expect(buffer.String()).To(ContainSubstring("Timeout: 10m0s"))
+		})
+
+		context("when the install process times out", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_TIMEOUT", "1ms")
+
+				installProcess.ExecuteCall.Stub = func(ctx gocontext.Context, _, _, _ string, _, _ []string) (pipinstall.InstallResult, error) {
+					<-ctx.Done()
+					return pipinstall.InstallResult{}, fmt.Errorf("pip install %w", gocontext.Cause(ctx))
+				}
+			})
+
+			it("returns an error naming the timeout", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("pip install timed out after 1ms (BP_PIP_TIMEOUT=1ms): context deadline exceeded"))
+				Expect(errors.Is(err, gocontext.DeadlineExceeded)).To(BeTrue())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when BP_PIP_TIMEOUT is invalid", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_TIMEOUT", "forever")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse BP_PIP_TIMEOUT value 'forever'")))
+					Expect(installProcess.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when BP_PIP_TIMEOUT is negative", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_TIMEOUT", "-1m")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("failed to parse BP_PIP_TIMEOUT value '-1m': negative duration"))
+				})
+			})
+		})
+	})
+
 	context("install process utilizes cache", func() {
 		it.Before(func() {
-			installProcess.ExecuteCall.Stub = func(_, _, cachePath string) error {
+			installProcess.ExecuteCall.Stub = func(_ gocontext.Context, _, _, cachePath string, _, _ []string) (pipinstall.InstallResult, error) {
 				Expect(os.MkdirAll(filepath.Join(cachePath, "something"), os.ModePerm)).To(Succeed())
-				return nil
+				return pipinstall.InstallResult{}, nil
 			}
 			buildContext.Plan.Entries[0].Metadata["launch"] = true
 			buildContext.Plan.Entries[0].Metadata["build"] = true
@@ -195,6 +896,57 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			Expect(packagesLayer.Build).To(BeTrue())
 			Expect(packagesLayer.Launch).To(BeTrue())
 			Expect(cacheLayer.Cache).To(BeTrue())
+			Expect(cacheLayer.Metadata).To(BeEmpty())
+		})
+
+		context("when the cache size is limited", func() {
+			it.Before(func() {
+				cacheProcess.ExecuteCall.Returns.CacheUsage = pipinstall.CacheUsage{
+					Limit:   100 * 1024 * 1024,
+					Before:  150 * 1024 * 1024,
+					After:   96 * 1024 * 1024,
+					Evicted: 12,
+				}
+			})
+
+			it("logs the cache size and stores it in the cache layer metadata", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers).To(HaveLen(2))
+
+				cacheLayer := result.Layers[1]
+				Expect(cacheLayer.Name).To(Equal("cache"))
+				Expect(cacheLayer.Metadata).To(Equal(map[string]interface{}{
+					"cache_size_limit":  int64(100 * 1024 * 1024),
+					"cache_size_before": int64(150 * 1024 * 1024),
+					"cache_size_after":  int64(96 * 1024 * 1024),
+				}))
+
+				Expect(buffer.String()).To(ContainSubstring("Enforcing pip cache size limit of 100.0 MiB"))
+				Expect(buffer.String()).To(ContainSubstring("Cache size before: 150.0 MiB"))
+				Expect(buffer.String()).To(ContainSubstring("Cache size after: 96.0 MiB (12 entries evicted)"))
+			})
+		})
+
+		context("when an earlier build limited the cache size", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layersDir, "cache.toml"), []byte(`
+[metadata]
+  cache_size_limit = 104857600
+  cache_size_before = 157286400
+  cache_size_after = 100663296
+`), 0600)).To(Succeed())
+			})
+
+			it("removes the cache size from the cache layer metadata", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				cacheLayer := result.Layers[1]
+				Expect(cacheLayer.Name).To(Equal("cache"))
+				Expect(cacheLayer.Metadata).To(BeEmpty())
+			})
 		})
 	})
 
@@ -236,6 +988,17 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when the cache process returns an error", func() {
+			it.Before(func() {
+				cacheProcess.ExecuteCall.Returns.Error = errors.New("could not evict cache entries")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("could not evict cache entries"))
+			})
+		})
+
 		context("when generating the SBOM returns an error", func() {
 			it.Before(func() {
 				buildContext.BuildpackInfo.SBOMFormats = []string{"random-format"}
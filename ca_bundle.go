@@ -0,0 +1,138 @@
+package pipinstall
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+// caBindingTypes are the types of the service bindings that provide CA
+// certificates to trust when installing packages.
+var caBindingTypes = []string{"ca-certificates", "pip"}
+
+// systemBundles are the usual locations of the CA certificate bundle of the
+// system, in the order they are looked up.
+var systemBundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",
+	"/etc/pki/tls/certs/ca-bundle.crt",
+	"/etc/ssl/ca-bundle.pem",
+	"/etc/pki/tls/cacert.pem",
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem",
+	"/etc/ssl/cert.pem",
+}
+
+// caBindings returns the service bindings that provide CA certificates.
+func caBindings(resolver BindingResolver, platformDir string) ([]servicebindings.Binding, error) {
+	var bindings []servicebindings.Binding
+	for _, typ := range caBindingTypes {
+		resolved, err := resolver.Resolve(typ, "", platformDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve '%s' service bindings: %w", typ, err)
+		}
+		bindings = append(bindings, resolved...)
+	}
+
+	return bindings, nil
+}
+
+// bindingCertificates returns the PEM certificates found in the entries of
+// the binding. Entries that contain no certificate, such as a pip.conf, are
+// ignored.
+func bindingCertificates(binding servicebindings.Binding) ([]*pem.Block, error) {
+	var names []string
+	for name := range binding.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var certificates []*pem.Block
+	for _, name := range names {
+		content, err := binding.Entries[name].ReadBytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entry '%s' of service binding '%s': %w", name, binding.Name, err)
+		}
+
+		for {
+			var block *pem.Block
+			block, content = pem.Decode(content)
+			if block == nil {
+				break
+			}
+
+			if block.Type == "CERTIFICATE" {
+				certificates = append(certificates, block)
+			}
+		}
+	}
+
+	return certificates, nil
+}
+
+// systemBundle returns the CA certificate bundle of the system, found in
+// SSL_CERT_FILE or in one of the systemBundles, or nothing when there is
+// none.
+func systemBundle() ([]byte, error) {
+	paths := systemBundles
+	if path, ok := os.LookupEnv("SSL_CERT_FILE"); ok {
+		paths = []string{path}
+	}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err == nil {
+			return content, nil
+		}
+
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read system CA certificates: %w", err)
+		}
+	}
+
+	return nil, nil
+}
+
+// writeCABundle writes the CA certificates of the system followed by those
+// of the bindings to path, and returns the number of certificates taken from
+// the bindings.
+func writeCABundle(path string, bindings []servicebindings.Binding) (int, error) {
+	bundle, err := systemBundle()
+	if err != nil {
+		return 0, err
+	}
+
+	buffer := bytes.NewBuffer(bundle)
+	if buffer.Len() > 0 && !bytes.HasSuffix(bundle, []byte("\n")) {
+		buffer.WriteString("\n")
+	}
+
+	var count int
+	for _, binding := range bindings {
+		certificates, err := bindingCertificates(binding)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, certificate := range certificates {
+			err = pem.Encode(buffer, certificate)
+			if err != nil {
+				return 0, err
+			}
+		}
+		count += len(certificates)
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+
+	err = os.WriteFile(path, buffer.Bytes(), 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write CA certificate bundle: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,28 @@
+//go:build linux
+
+package pipinstall
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// lastUsed returns the time a cached file was last read or written. pip does
+// not touch cache entries when it reads them, so the access time is used
+// where the filesystem records it.
+func lastUsed(info os.FileInfo) time.Time {
+	modified := info.ModTime()
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return modified
+	}
+
+	accessed := time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec))
+	if accessed.After(modified) {
+		return accessed
+	}
+
+	return modified
+}
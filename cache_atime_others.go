@@ -0,0 +1,13 @@
+//go:build !linux
+
+package pipinstall
+
+import (
+	"os"
+	"time"
+)
+
+// lastUsed returns the time a cached file was last written.
+func lastUsed(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,72 @@
+package pipinstall
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+var partitionUnsafePattern = regexp.MustCompile(`[^A-Za-z0-9._]+`)
+
+// legacyPipCacheEntries are the entries that pip writes to its cache
+// directory, which were found at the root of the cache layer before the pip
+// cache was partitioned by interpreter.
+var legacyPipCacheEntries = []string{"http", "http-v2", "wheels", "selfcheck", "selfcheck.json"}
+
+// cachePartition returns the name of the pip cache partition for the
+// interpreter described by the environment, made of its implementation,
+// its major and minor version and its architecture, e.g.
+// "cpython-3.12-x86_64".
+func cachePartition(environment pep508.Environment) string {
+	var parts []string
+	for _, part := range []string{environment.ImplementationName, environment.PythonVersion, environment.PlatformMachine} {
+		part = partitionUnsafePattern.ReplaceAllString(strings.ToLower(part), "_")
+		if part == "" {
+			part = "unknown"
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, "-")
+}
+
+// pipCachePartition returns the path of the pip cache partition for the
+// interpreter in cachePath, which is cachePath/pip/<partition>. The
+// partitions of other interpreters, whose wheels were built for a different
+// ABI, are removed along with a pip cache that predates the partitioning.
+func (p PipInstallProcess) pipCachePartition(cachePath string, environment pep508.Environment) (string, error) {
+	partition := cachePartition(environment)
+	p.logger.Subprocess("Using pip cache partition '%s'", partition)
+
+	root := filepath.Join(cachePath, "pip")
+
+	entries, err := os.ReadDir(root)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read the pip cache: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == partition {
+			continue
+		}
+
+		p.logger.Subprocess("Removing pip cache partition '%s' of a previous interpreter", entry.Name())
+		err = os.RemoveAll(filepath.Join(root, entry.Name()))
+		if err != nil {
+			return "", fmt.Errorf("failed to remove pip cache partition: %w", err)
+		}
+	}
+
+	for _, name := range legacyPipCacheEntries {
+		err = os.RemoveAll(filepath.Join(cachePath, name))
+		if err != nil {
+			return "", fmt.Errorf("failed to remove pip cache: %w", err)
+		}
+	}
+
+	return filepath.Join(root, partition), nil
+}
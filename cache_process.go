@@ -0,0 +1,286 @@
+package pipinstall
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheUsage describes the size of the pip cache before and after the size
+// limit given in `BP_PIP_CACHE_MAX_SIZE` was enforced.
+type CacheUsage struct {
+	// Limit is the maximum size of the cache in bytes, or 0 when the cache
+	// size is not limited.
+	Limit int64
+
+	// Before and After are the sizes of the cache in bytes.
+	Before int64
+	After  int64
+
+	// Evicted is the number of cache entries that were removed.
+	Evicted int
+}
+
+// cacheEntry is a unit of the pip cache that is evicted as a whole: an HTTP
+// response, or a directory holding a wheel built by pip.
+type cacheEntry struct {
+	paths    []string
+	size     int64
+	lastUsed time.Time
+}
+
+// PipCacheProcess implements the CacheProcess interface.
+type PipCacheProcess struct{}
+
+// NewPipCacheProcess creates an instance of the PipCacheProcess.
+func NewPipCacheProcess() PipCacheProcess {
+	return PipCacheProcess{}
+}
+
+// Execute enforces the size limit given in `BP_PIP_CACHE_MAX_SIZE` on the
+// HTTP and wheels caches of the pip cache partitions in cacheDir, by
+// evicting the least recently used entries until the caches fit. The limit
+// is given in bytes, or with a binary unit such as "500M" or "2GiB". Other
+// contents of the cacheDir, such as cached VCS clones, are neither counted
+// nor evicted.
+//
+// When `BP_PIP_CACHE_MAX_SIZE` is not set, the cache is left untouched and an
+// empty CacheUsage is returned.
+func (p PipCacheProcess) Execute(cacheDir string) (CacheUsage, error) {
+	value, exists := os.LookupEnv("BP_PIP_CACHE_MAX_SIZE")
+	if !exists {
+		return CacheUsage{}, nil
+	}
+
+	limit, err := parseSize(value)
+	if err != nil {
+		return CacheUsage{}, fmt.Errorf("failed to parse BP_PIP_CACHE_MAX_SIZE value '%s': %w", value, err)
+	}
+
+	entries, err := cacheEntries(cacheDir)
+	if err != nil {
+		return CacheUsage{}, fmt.Errorf("failed to inspect the pip cache: %w", err)
+	}
+
+	usage := CacheUsage{Limit: limit}
+	for _, entry := range entries {
+		usage.Before += entry.size
+	}
+	usage.After = usage.Before
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].lastUsed.Before(entries[j].lastUsed)
+	})
+
+	for _, entry := range entries {
+		if usage.After <= limit {
+			break
+		}
+
+		for _, path := range entry.paths {
+			err = os.Remove(path)
+			if err != nil && !os.IsNotExist(err) {
+				return CacheUsage{}, fmt.Errorf("failed to evict pip cache entry: %w", err)
+			}
+		}
+
+		usage.After -= entry.size
+		usage.Evicted++
+	}
+
+	if usage.Evicted > 0 {
+		roots, err := pipCacheRoots(cacheDir)
+		if err != nil {
+			return CacheUsage{}, fmt.Errorf("failed to evict pip cache entry: %w", err)
+		}
+
+		for _, root := range roots {
+			err = removeEmptyDirs(root)
+			if err != nil {
+				return CacheUsage{}, fmt.Errorf("failed to evict pip cache entry: %w", err)
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// pipCacheDirs are the subdirectories of the pip cache directory that hold
+// HTTP responses (http-v2 since pip 23.3, http before) and built wheels.
+var pipCacheDirs = []string{"http", "http-v2", "wheels"}
+
+// pipCacheRoots returns the HTTP and wheels cache directories of the pip
+// cache partitions in cacheDir.
+func pipCacheRoots(cacheDir string) ([]string, error) {
+	var roots []string
+	for _, dir := range pipCacheDirs {
+		matches, err := filepath.Glob(filepath.Join(cacheDir, "pip", "*", dir))
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, matches...)
+	}
+
+	return roots, nil
+}
+
+// cacheEntries returns the entries of the pip HTTP and wheels caches in
+// cacheDir. HTTP responses are stored as a file, along with a ".body" file
+// in the http-v2 cache, while each built wheel is stored in a directory of
+// its own.
+func cacheEntries(cacheDir string) ([]*cacheEntry, error) {
+	roots, err := pipCacheRoots(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*cacheEntry
+	for _, root := range roots {
+		wheels := filepath.Base(root) == "wheels"
+		byKey := map[string]*cacheEntry{}
+
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == root {
+					return filepath.SkipDir
+				}
+				return err
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			key := strings.TrimSuffix(path, ".body")
+			if wheels {
+				key = filepath.Dir(path)
+			}
+
+			entry, ok := byKey[key]
+			if !ok {
+				entry = &cacheEntry{}
+				byKey[key] = entry
+				entries = append(entries, entry)
+			}
+
+			entry.paths = append(entry.paths, path)
+			entry.size += info.Size()
+			if used := lastUsed(info); used.After(entry.lastUsed) {
+				entry.lastUsed = used
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// removeEmptyDirs removes the empty directories below root.
+func removeEmptyDirs(root string) error {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Remove the deepest directories first, so that their parents may become
+	// empty in turn.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		entries, err := os.ReadDir(dirs[i])
+		if err != nil {
+			return err
+		}
+
+		if len(entries) == 0 {
+			err = os.Remove(dirs[i])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+var sizeUnits = map[string]int64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// parseSize parses a size given in bytes, or with a binary unit such as
+// "512K", "500M", "2G" or "1.5GiB". The units "KB", "MB", etc. are read as
+// their binary counterparts as well.
+func parseSize(value string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+
+	index := strings.IndexFunc(text, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if index < 0 {
+		index = len(text)
+	}
+
+	number, unit := text[:index], strings.TrimSpace(text[index:])
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "IB"), "B")
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid size '%s'", value)
+	}
+
+	size, err := strconv.ParseFloat(number, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size '%s'", value)
+	}
+
+	return int64(size * float64(multiplier)), nil
+}
+
+// formatSize formats a size in bytes with a binary unit, e.g. "1.5 GiB".
+func formatSize(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -0,0 +1,150 @@
+package pipinstall_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCacheProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cacheDir string
+
+		process pipinstall.PipCacheProcess
+	)
+
+	// writeEntry writes a cache file of the given size, last used the given
+	// number of hours ago.
+	writeEntry := func(path string, size int, hoursAgo int) {
+		path = filepath.Join(cacheDir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(path, make([]byte, size), 0600)).To(Succeed())
+
+		used := time.Now().Add(-time.Duration(hoursAgo) * time.Hour)
+		Expect(os.Chtimes(path, used, used)).To(Succeed())
+	}
+
+	it.Before(func() {
+		cacheDir = t.TempDir()
+
+		writeEntry("pip/cpython-3.12-x86_64/http-v2/a/b/oldest", 1024, 5)
+		writeEntry("pip/cpython-3.12-x86_64/http-v2/a/b/oldest.body", 3072, 5)
+		writeEntry("pip/cpython-3.12-x86_64/http/c/d/old", 2048, 4)
+		writeEntry("pip/cpython-3.12-x86_64/wheels/e/f/g/numpy-2.0.0-cp312-cp312-linux_x86_64.whl", 4096, 3)
+		writeEntry("pip/cpython-3.12-x86_64/wheels/e/f/g/origin.json", 100, 3)
+		writeEntry("pip/cpython-3.12-x86_64/http-v2/h/i/recent", 1024, 1)
+		writeEntry("vcs/git/some-repo/HEAD", 8192, 10)
+
+		process = pipinstall.NewPipCacheProcess()
+	})
+
+	context("Execute", func() {
+		it("leaves the cache untouched when BP_PIP_CACHE_MAX_SIZE is not set", func() {
+			usage, err := process.Execute(cacheDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage).To(Equal(pipinstall.CacheUsage{}))
+
+			Expect(filepath.Join(cacheDir, "pip/cpython-3.12-x86_64/http-v2/a/b/oldest")).To(BeARegularFile())
+		})
+
+		context("when the cache fits within BP_PIP_CACHE_MAX_SIZE", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_CACHE_MAX_SIZE", "1M")
+			})
+
+			it("does not evict any entries", func() {
+				usage, err := process.Execute(cacheDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(usage).To(Equal(pipinstall.CacheUsage{
+					Limit:  1024 * 1024,
+					Before: 11364,
+					After:  11364,
+				}))
+
+				Expect(filepath.Join(cacheDir, "pip/cpython-3.12-x86_64/http-v2/a/b/oldest")).To(BeARegularFile())
+			})
+		})
+
+		context("when the cache exceeds BP_PIP_CACHE_MAX_SIZE", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_CACHE_MAX_SIZE", "6KiB")
+			})
+
+			it("evicts the least recently used entries until the cache fits", func() {
+				usage, err := process.Execute(cacheDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(usage).To(Equal(pipinstall.CacheUsage{
+					Limit:   6 * 1024,
+					Before:  11364,
+					After:   5220,
+					Evicted: 2,
+				}))
+
+				Expect(filepath.Join(cacheDir, "pip/cpython-3.12-x86_64/http-v2/a")).NotTo(BeADirectory())
+				Expect(filepath.Join(cacheDir, "pip/cpython-3.12-x86_64/http/c")).NotTo(BeADirectory())
+				Expect(filepath.Join(cacheDir, "pip/cpython-3.12-x86_64/http-v2")).To(BeADirectory())
+				Expect(filepath.Join(cacheDir, "pip/cpython-3.12-x86_64/http-v2/h/i/recent")).To(BeARegularFile())
+				Expect(filepath.Join(cacheDir, "pip/cpython-3.12-x86_64/wheels/e/f/g/origin.json")).To(BeARegularFile())
+				Expect(filepath.Join(cacheDir, "vcs/git/some-repo/HEAD")).To(BeARegularFile())
+			})
+		})
+
+		context("when the cache directory does not exist", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_CACHE_MAX_SIZE", "100")
+			})
+
+			it("reports an empty cache", func() {
+				usage, err := process.Execute(filepath.Join(cacheDir, "missing"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(usage).To(Equal(pipinstall.CacheUsage{Limit: 100}))
+			})
+		})
+
+		context("when BP_PIP_CACHE_MAX_SIZE is given in different units", func() {
+			for _, example := range []struct {
+				value string
+				limit int64
+			}{
+				{"512", 512},
+				{"512B", 512},
+				{"2k", 2 * 1024},
+				{"500M", 500 * 1024 * 1024},
+				{"500MB", 500 * 1024 * 1024},
+				{"1.5GiB", 3 * 1024 * 1024 * 1024 / 2},
+				{" 1 T ", 1024 * 1024 * 1024 * 1024},
+			} {
+				value, limit := example.value, example.limit
+
+				it("parses '"+value+"'", func() {
+					t.Setenv("BP_PIP_CACHE_MAX_SIZE", value)
+
+					usage, err := process.Execute(filepath.Join(cacheDir, "missing"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(usage.Limit).To(Equal(limit))
+				})
+			}
+		})
+
+		context("failure cases", func() {
+			context("when BP_PIP_CACHE_MAX_SIZE is invalid", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_CACHE_MAX_SIZE", "10 apples")
+				})
+
+				it("returns an error", func() {
+					_, err := process.Execute(cacheDir)
+					Expect(err).To(MatchError("failed to parse BP_PIP_CACHE_MAX_SIZE value '10 apples': invalid size '10 apples'"))
+				})
+			})
+		})
+	})
+}
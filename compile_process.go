@@ -0,0 +1,78 @@
+package pipinstall
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// compileMode returns how the installed packages are byte-compiled, given in
+// BP_PIP_COMPILE: "parallel" (the default) byte-compiles the site-packages
+// directory with one worker per CPU after pip has installed the packages,
+// "serial" lets pip byte-compile each package as it is installed, and "off"
+// does not byte-compile the packages at all.
+func compileMode() (string, error) {
+	mode, exists := os.LookupEnv("BP_PIP_COMPILE")
+	if !exists {
+		return "parallel", nil
+	}
+
+	switch mode {
+	case "parallel", "serial", "off":
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("invalid BP_PIP_COMPILE value '%s': must be one of 'parallel', 'serial' or 'off'", mode)
+}
+
+// CompileProcess implements the BytecodeProcess interface.
+type CompileProcess struct {
+	executable Executable
+	logger     scribe.Emitter
+}
+
+// NewCompileProcess creates an instance of the CompileProcess given an
+// Executable that runs `python`.
+func NewCompileProcess(executable Executable, logger scribe.Emitter) CompileProcess {
+	return CompileProcess{
+		executable: executable,
+		logger:     logger,
+	}
+}
+
+// Execute runs a python command to byte-compile the sitePackagesPath, with
+// as many workers as there are CPUs. Files that cannot be compiled, such as
+// test fixtures or modules written for Python 2, are logged as a warning and
+// left uncompiled, as pip does when it compiles the packages itself.
+func (p CompileProcess) Execute(sitePackagesPath string) error {
+	buffer := bytes.NewBuffer(nil)
+
+	err := p.executable.Execute(pexec.Execution{
+		Args:   []string{"-m", "compileall", "-q", "-j", "0", sitePackagesPath},
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+	if err != nil {
+		var failures []string
+		for _, line := range strings.Split(buffer.String(), "\n") {
+			if strings.HasPrefix(line, "*** Error compiling") {
+				failures = append(failures, strings.TrimPrefix(line, "*** "))
+			}
+		}
+
+		if len(failures) == 0 {
+			return fmt.Errorf("failed to compile bytecode:\n%s\nerror: %w", buffer.String(), err)
+		}
+
+		p.logger.Subprocess("Warning: %d files could not be compiled and are left uncompiled:", len(failures))
+		for _, failure := range failures {
+			p.logger.Action("%s", failure)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package pipinstall_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+	"github.com/paketo-buildpacks/pip-install/fakes"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCompileProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		executable *fakes.Executable
+		buffer     *bytes.Buffer
+
+		process pipinstall.CompileProcess
+	)
+
+	it.Before(func() {
+		executable = &fakes.Executable{}
+
+		buffer = bytes.NewBuffer(nil)
+
+		process = pipinstall.NewCompileProcess(executable, scribe.NewEmitter(buffer))
+	})
+
+	context("Execute", func() {
+		it("byte-compiles the site-packages with a worker per CPU", func() {
+			err := process.Execute("some-site-packages-path")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"-m", "compileall", "-q", "-j", "0", "some-site-packages-path"}))
+		})
+
+		context("when some files cannot be compiled", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					_, _ = fmt.Fprintln(execution.Stdout, "*** Error compiling 'some-site-packages-path/fixtures/broken.py'...")
+					_, _ = fmt.Fprintln(execution.Stdout, "  File \"some-site-packages-path/fixtures/broken.py\", line 1")
+					_, _ = fmt.Fprintln(execution.Stdout, "*** Error compiling 'some-site-packages-path/legacy/py2.py'...")
+					return errors.New("exit status 1")
+				}
+			})
+
+			it("warns about them and succeeds", func() {
+				err := process.Execute("some-site-packages-path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Warning: 2 files could not be compiled and are left uncompiled:"))
+				Expect(buffer.String()).To(ContainSubstring("Error compiling 'some-site-packages-path/fixtures/broken.py'..."))
+				Expect(buffer.String()).To(ContainSubstring("Error compiling 'some-site-packages-path/legacy/py2.py'..."))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when compileall fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stdout, "/usr/bin/python: No module named compileall")
+						return errors.New("exit status 1")
+					}
+				})
+
+				it("returns an error", func() {
+					err := process.Execute("some-site-packages-path")
+					Expect(err).To(MatchError("failed to compile bytecode:\n/usr/bin/python: No module named compileall\n\nerror: exit status 1"))
+				})
+			})
+		})
+	})
+}
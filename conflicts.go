@@ -0,0 +1,123 @@
+package pipinstall
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/paketo-buildpacks/pip-install/pep440"
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+// requestGroup holds the lines that request the same project.
+type requestGroup struct {
+	name         string
+	lines        []RequirementLine
+	requirements []pep508.Requirement
+}
+
+// conflict returns the reason the requests of the group cannot be satisfied
+// together, or an empty string if they can.
+func (g requestGroup) conflict() string {
+	var (
+		urls      = map[string]bool{}
+		specifier pep440.SpecifierSet
+	)
+
+	for _, requirement := range g.requirements {
+		if requirement.URL != "" {
+			urls[requirement.URL] = true
+		}
+		specifier = append(specifier, requirement.Specifier...)
+	}
+
+	if len(urls) > 1 {
+		return "different direct references"
+	}
+
+	if !specifier.Satisfiable() {
+		return fmt.Sprintf("no version satisfies '%s'", specifier)
+	}
+
+	return ""
+}
+
+// reportConflicts merges the named requirements in lines, across all of the
+// requirements files and pyproject.toml, and logs a table of the projects that
+// are requested more than once, along with where and how. Requests that
+// cannot be satisfied together, because their specifiers contradict each
+// other or they name different direct references, are reported as
+// conflicts, which pip will fail to resolve.
+func (p PipInstallProcess) reportConflicts(lines []RequirementLine) {
+	var (
+		groups []*requestGroup
+		byName = map[string]*requestGroup{}
+	)
+
+	for _, line := range lines {
+		if line.Requirement == "" {
+			continue
+		}
+
+		requirement, err := pep508.ParseRequirement(line.Requirement)
+		if err != nil {
+			continue
+		}
+
+		name := NormalizeName(requirement.Name)
+		group, ok := byName[name]
+		if !ok {
+			group = &requestGroup{name: name}
+			byName[name] = group
+			groups = append(groups, group)
+		}
+
+		group.lines = append(group.lines, line)
+		group.requirements = append(group.requirements, requirement)
+	}
+
+	var (
+		table     bytes.Buffer
+		conflicts []string
+	)
+
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PACKAGE\tSTATUS\tLOCATION\tREQUIREMENT")
+
+	for _, group := range groups {
+		if len(group.lines) < 2 {
+			continue
+		}
+
+		status := "duplicate"
+		if reason := group.conflict(); reason != "" {
+			status = "conflict"
+			conflicts = append(conflicts, fmt.Sprintf("'%s': %s", group.name, reason))
+		}
+
+		for i, line := range group.lines {
+			if i == 0 {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", group.name, status, line.Location(), redactCredentials(line.Requirement))
+				continue
+			}
+			fmt.Fprintf(writer, "\t\t%s\t%s\n", line.Location(), redactCredentials(line.Requirement))
+		}
+	}
+
+	writer.Flush()
+
+	rows := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	if len(rows) < 2 {
+		return
+	}
+
+	p.logger.Subprocess("Requirements requested more than once:")
+	for _, row := range rows {
+		p.logger.Action("%s", strings.TrimRight(row, " "))
+	}
+
+	for _, conflict := range conflicts {
+		p.logger.Subprocess("Warning: conflicting requirements for %s", conflict)
+	}
+}
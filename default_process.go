@@ -0,0 +1,74 @@
+package pipinstall
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+// defaultProcess returns the console script and the arguments of the
+// default launch process, given in BP_PIP_DEFAULT_PROCESS as the name of the
+// script followed by its arguments, split on whitespace. The script is empty
+// when the variable is not set.
+func defaultProcess() (string, []string, error) {
+	value, exists := os.LookupEnv("BP_PIP_DEFAULT_PROCESS")
+	if !exists {
+		return "", nil, nil
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return "", nil, fmt.Errorf("invalid BP_PIP_DEFAULT_PROCESS value '%s': must name a console script", value)
+	}
+
+	script := fields[0]
+	if strings.ContainsRune(script, '/') {
+		return "", nil, fmt.Errorf("invalid BP_PIP_DEFAULT_PROCESS value '%s': '%s' is not the name of a console script", value, script)
+	}
+
+	return script, fields[1:], nil
+}
+
+// launchProcess returns the web process that runs the console script, with
+// the args, from the bin directory of the packages layer at layerPath, where
+// every install layout places the scripts. It fails when none of the
+// distributions installed in the layer declares the script in its
+// entry_points.txt.
+func launchProcess(layerPath, script string, args []string) (packit.Process, error) {
+	distributions, err := installedDistributions(layerPath)
+	if err != nil {
+		return packit.Process{}, fmt.Errorf("failed to list installed distributions: %w", err)
+	}
+
+	var available []string
+	for _, dist := range distributions {
+		scripts, err := consoleScripts(dist.Path)
+		if err != nil {
+			return packit.Process{}, err
+		}
+
+		for _, name := range scripts {
+			if name == script {
+				return packit.Process{
+					Type:    "web",
+					Command: filepath.Join(layerPath, "bin", script),
+					Args:    args,
+					Default: true,
+				}, nil
+			}
+		}
+
+		available = append(available, scripts...)
+	}
+
+	if len(available) == 0 {
+		return packit.Process{}, fmt.Errorf("BP_PIP_DEFAULT_PROCESS names console script '%s', but none of the installed packages declare console scripts", script)
+	}
+
+	sort.Strings(available)
+	return packit.Process{}, fmt.Errorf("BP_PIP_DEFAULT_PROCESS names console script '%s', which none of the installed packages declare: choose one of '%s'", script, strings.Join(available, "', '"))
+}
@@ -21,6 +21,10 @@ type BuildPlanMetadata struct {
 //
 // Detection will contribute a Build Plan that provides site-packages,
 // and requires cpython and pip at build.
+//
+// When BP_PIP_PYPROJECT is enabled, a pyproject.toml declaring a PEP 621
+// [project] table is sufficient for detection to pass, even without a
+// requirements file.
 func Detect() packit.DetectFunc {
 	return func(context packit.DetectContext) (packit.DetectResult, error) {
 		requirementsFile := "requirements.txt"
@@ -29,6 +33,34 @@ func Detect() packit.DetectFunc {
 			requirementsFile = envRequirement
 		}
 
+		pyProjectMode, err := pyProjectEnabled()
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		// A PEP 621 project whose dependencies are dynamic does not declare
+		// them in the pyproject.toml, so it cannot stand in for a
+		// requirements.txt.
+		// A pyproject.toml that cannot be parsed is left out, so that the
+		// requirements files are used instead, as they are without
+		// BP_PIP_PYPROJECT.
+		hasPyProject, dynamicDependencies := false, false
+		var pyProjectErr error
+		if pyProjectMode {
+			pyProjectPath := filepath.Join(context.WorkingDir, "pyproject.toml")
+			exists, err := fs.Exists(pyProjectPath)
+			if err != nil {
+				return packit.DetectResult{}, err
+			}
+
+			if exists {
+				var pyProject PyProject
+				pyProject, pyProjectErr = ParsePyProject(pyProjectPath)
+				hasPyProject = pyProject.Defined && !pyProject.IsDynamic("dependencies")
+				dynamicDependencies = pyProject.Defined && pyProject.IsDynamic("dependencies")
+			}
+		}
+
 		missingRequirementFiles := []string{}
 		allRequirementsFilesExist := true
 		for _, filename := range strings.Split(requirementsFile, " ") {
@@ -42,7 +74,22 @@ func Detect() packit.DetectFunc {
 			allRequirementsFilesExist = allRequirementsFilesExist && found
 		}
 
+		// Without an explicit BP_PIP_REQUIREMENT, a PEP 621 project does not
+		// need a requirements.txt alongside it.
+		if hasPyProject && !requirementEnvExists {
+			allRequirementsFilesExist = true
+		}
+
 		if !allRequirementsFilesExist {
+			if pyProjectErr != nil {
+				return packit.DetectResult{}, packit.Fail.WithMessage("requirements file not found at: '%s' and 'pyproject.toml' could not be parsed: %s", strings.Join(missingRequirementFiles, "', '"), pyProjectErr)
+			}
+			if dynamicDependencies {
+				return packit.DetectResult{}, packit.Fail.WithMessage("requirements file not found at: '%s' and the dependencies in 'pyproject.toml' are dynamic", strings.Join(missingRequirementFiles, "', '"))
+			}
+			if pyProjectMode && !hasPyProject {
+				return packit.DetectResult{}, packit.Fail.WithMessage("requirements file not found at: '%s' and no [project] table found in 'pyproject.toml'", strings.Join(missingRequirementFiles, "', '"))
+			}
 			return packit.DetectResult{}, packit.Fail.WithMessage("requirements file not found at: '%s'", strings.Join(missingRequirementFiles, "', '"))
 		}
 
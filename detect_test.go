@@ -164,6 +164,125 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("BP_PIP_PYPROJECT is enabled", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_PYPROJECT", "true")
+
+				Expect(os.Remove(filepath.Join(workingDir, "requirements.txt"))).To(Succeed())
+
+				err := os.WriteFile(filepath.Join(workingDir, "pyproject.toml"), []byte(`
+[project]
+name = "some-app"
+dependencies = ["flask"]
+`), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("detects on a pyproject.toml with a [project] table", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Plan.Provides).To(Equal([]packit.BuildPlanProvision{
+					{Name: pipinstall.SitePackages},
+				}))
+			})
+
+			context("and the pyproject.toml has no [project] table", func() {
+				it.Before(func() {
+					err := os.WriteFile(filepath.Join(workingDir, "pyproject.toml"), []byte("[tool.black]\n"), 0644)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				it("fails detection", func() {
+					_, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+					})
+					Expect(err).To(MatchError(packit.Fail.WithMessage("requirements file not found at: 'requirements.txt' and no [project] table found in 'pyproject.toml'")))
+				})
+			})
+
+			context("and the dependencies in the pyproject.toml are dynamic", func() {
+				it.Before(func() {
+					err := os.WriteFile(filepath.Join(workingDir, "pyproject.toml"), []byte("[project]\nname = \"some-app\"\ndynamic = [\"dependencies\"]\n"), 0644)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				it("fails detection", func() {
+					_, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+					})
+					Expect(err).To(MatchError(packit.Fail.WithMessage("requirements file not found at: 'requirements.txt' and the dependencies in 'pyproject.toml' are dynamic")))
+				})
+
+				context("and a requirements.txt exists", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(filepath.Join(workingDir, "requirements.txt"), nil, 0644)).To(Succeed())
+					})
+
+					it("detects on the requirements.txt", func() {
+						_, err := detect(packit.DetectContext{
+							WorkingDir: workingDir,
+						})
+						Expect(err).NotTo(HaveOccurred())
+					})
+				})
+			})
+
+			context("and the pyproject.toml is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "pyproject.toml"), []byte("[project"), 0644)).To(Succeed())
+				})
+
+				it("fails detection", func() {
+					_, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+					})
+					Expect(err).To(MatchError(ContainSubstring("requirements file not found at: 'requirements.txt' and 'pyproject.toml' could not be parsed: failed to parse")))
+				})
+
+				context("and a requirements.txt exists", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(filepath.Join(workingDir, "requirements.txt"), nil, 0644)).To(Succeed())
+					})
+
+					it("detects on the requirements.txt", func() {
+						_, err := detect(packit.DetectContext{
+							WorkingDir: workingDir,
+						})
+						Expect(err).NotTo(HaveOccurred())
+					})
+				})
+			})
+
+			context("and BP_PIP_REQUIREMENT points to a missing file", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_REQUIREMENT", "requirements-extra.txt")
+				})
+
+				it("fails detection", func() {
+					_, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+					})
+					Expect(err).To(MatchError(packit.Fail.WithMessage("requirements file not found at: 'requirements-extra.txt'")))
+				})
+			})
+
+			context("and BP_PIP_PYPROJECT is disabled", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_PYPROJECT", "false")
+				})
+
+				it("fails detection", func() {
+					_, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+					})
+					Expect(err).To(MatchError(packit.Fail.WithMessage("requirements file not found at: 'requirements.txt'")))
+				})
+			})
+		})
+
 		context("failure cases", func() {
 			context("when the requirements.txt cannot be read", func() {
 				it.Before(func() {
@@ -181,6 +300,20 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 					Expect(err).To(MatchError(ContainSubstring("permission denied")))
 				})
 			})
+
+			context("when BP_PIP_PYPROJECT is not a boolean", func() {
+				it.Before(func() {
+					t.Setenv("BP_PIP_PYPROJECT", "some-value")
+				})
+
+				it("returns an error", func() {
+					_, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+					})
+					Expect(err).To(MatchError(ContainSubstring("failed to parse BP_PIP_PYPROJECT value 'some-value'")))
+				})
+			})
+
 		})
 
 	})
@@ -0,0 +1,93 @@
+package pipinstall
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// distribution is a distribution installed in the packages layer.
+type distribution struct {
+	// Name and Version are taken from the METADATA of the distribution.
+	Name    string
+	Version string
+
+	// Path is the path of the .dist-info directory of the distribution.
+	Path string
+
+	// Direct denotes that the distribution was installed from a direct
+	// reference, such as a URL, a local project or a VCS repository, and
+	// therefore has a direct_url.json.
+	Direct bool
+}
+
+// installedDistributions returns the distributions installed in targetPath,
+// sorted by their normalized name.
+func installedDistributions(targetPath string) ([]distribution, error) {
+	var distributions []distribution
+
+	if _, err := os.Stat(targetPath); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	err := filepath.WalkDir(targetPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".dist-info") {
+			return nil
+		}
+
+		metadata, err := os.Open(filepath.Join(path, "METADATA"))
+		if errors.Is(err, os.ErrNotExist) {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		defer metadata.Close()
+
+		dist := distribution{Path: path}
+
+		scanner := bufio.NewScanner(metadata)
+		for scanner.Scan() && scanner.Text() != "" {
+			key, value, found := strings.Cut(scanner.Text(), ":")
+			if !found {
+				continue
+			}
+
+			switch strings.ToLower(key) {
+			case "name":
+				dist.Name = strings.TrimSpace(value)
+			case "version":
+				dist.Version = strings.TrimSpace(value)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read %s: %w", metadata.Name(), err)
+		}
+
+		if _, err := os.Stat(filepath.Join(path, "direct_url.json")); err == nil {
+			dist.Direct = true
+		}
+
+		distributions = append(distributions, dist)
+
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(distributions, func(i, j int) bool {
+		return NormalizeName(distributions[i].Name) < NormalizeName(distributions[j].Name)
+	})
+
+	return distributions, nil
+}
@@ -0,0 +1,44 @@
+package pipinstall
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// envMode returns how the packages layer is exposed to the app, given in
+// BP_PIP_ENV_MODE: "pythonpath" (the default) prepends the site-packages
+// directory onto PYTHONPATH, and "userbase" sets PYTHONUSERBASE to the layer,
+// so that the site module treats the site-packages directory as the user
+// site directory and processes its .pth files.
+func envMode() (string, error) {
+	mode, exists := os.LookupEnv("BP_PIP_ENV_MODE")
+	if !exists {
+		return "pythonpath", nil
+	}
+
+	switch mode {
+	case "pythonpath", "userbase":
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("invalid BP_PIP_ENV_MODE value '%s': must be one of 'pythonpath' or 'userbase'", mode)
+}
+
+// pthFiles returns the names of the .pth files in the site-packages
+// directory, sorted.
+func pthFiles(sitePackagesPath string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(sitePackagesPath, "*.pth"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -0,0 +1,79 @@
+package pipinstall
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/pip-install/pep425"
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+// environmentScript prints the PEP 508 marker environment of the running
+// interpreter, computed in the same way as the packaging library does.
+const environmentScript = `import json, os, platform, sys
+def version(info):
+    result = "{0.major}.{0.minor}.{0.micro}".format(info)
+    if info.releaselevel != "final":
+        result += info.releaselevel[0] + str(info.serial)
+    return result
+print(json.dumps({
+    "implementation_name": sys.implementation.name,
+    "implementation_version": version(sys.implementation.version),
+    "os_name": os.name,
+    "platform_machine": platform.machine(),
+    "platform_python_implementation": platform.python_implementation(),
+    "platform_release": platform.release(),
+    "platform_system": platform.system(),
+    "platform_version": platform.version(),
+    "python_full_version": platform.python_version(),
+    "python_version": ".".join(platform.python_version_tuple()[:2]),
+    "sys_platform": sys.platform,
+}))
+`
+
+// EnvironmentProcess implements the MarkerEnvironmentProcess interface.
+type EnvironmentProcess struct {
+	executable Executable
+}
+
+// NewEnvironmentProcess creates an instance of the EnvironmentProcess given an
+// Executable that runs `python`.
+func NewEnvironmentProcess(executable Executable) EnvironmentProcess {
+	return EnvironmentProcess{
+		executable: executable,
+	}
+}
+
+// Execute runs a python command to determine the environment that markers
+// are evaluated against. The platform_machine is taken from the target
+// architecture of the build, given in `CNB_TARGET_ARCH`, when it is set.
+func (p EnvironmentProcess) Execute() (pep508.Environment, error) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+
+	err := p.executable.Execute(pexec.Execution{
+		Args:   []string{"-c", environmentScript},
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		return pep508.Environment{}, fmt.Errorf("failed to determine the interpreter environment:\n%s\nerror: %w", stderr.String(), err)
+	}
+
+	var environment pep508.Environment
+	err = json.Unmarshal(stdout.Bytes(), &environment)
+	if err != nil {
+		return pep508.Environment{}, fmt.Errorf("failed to parse the interpreter environment: %w", err)
+	}
+
+	if arch, ok := os.LookupEnv("CNB_TARGET_ARCH"); ok {
+		if machine, ok := pep425.Machines[arch]; ok {
+			environment.PlatformMachine = machine
+		}
+	}
+
+	return environment, nil
+}
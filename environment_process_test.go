@@ -0,0 +1,98 @@
+package pipinstall_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+	"github.com/paketo-buildpacks/pip-install/fakes"
+	"github.com/paketo-buildpacks/pip-install/pep508"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testEnvironmentProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		executable *fakes.Executable
+
+		process pipinstall.EnvironmentProcess
+	)
+
+	it.Before(func() {
+		executable = &fakes.Executable{}
+		executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+			_, err := fmt.Fprintln(execution.Stdout, `{"implementation_name": "cpython", "implementation_version": "3.12.4", "os_name": "posix", "platform_machine": "x86_64", "platform_python_implementation": "CPython", "platform_release": "6.1.0", "platform_system": "Linux", "platform_version": "#1 SMP", "python_full_version": "3.12.4", "python_version": "3.12", "sys_platform": "linux"}`)
+			Expect(err).NotTo(HaveOccurred())
+			return nil
+		}
+
+		process = pipinstall.NewEnvironmentProcess(executable)
+	})
+
+	context("Execute", func() {
+		it("returns the marker environment of the interpreter", func() {
+			environment, err := process.Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executable.ExecuteCall.Receives.Execution.Args[0]).To(Equal("-c"))
+			Expect(environment).To(Equal(pep508.Environment{
+				ImplementationName:           "cpython",
+				ImplementationVersion:        "3.12.4",
+				OSName:                       "posix",
+				PlatformMachine:              "x86_64",
+				PlatformPythonImplementation: "CPython",
+				PlatformRelease:              "6.1.0",
+				PlatformSystem:               "Linux",
+				PlatformVersion:              "#1 SMP",
+				PythonFullVersion:            "3.12.4",
+				PythonVersion:                "3.12",
+				SysPlatform:                  "linux",
+			}))
+		})
+
+		context("when CNB_TARGET_ARCH is set", func() {
+			it.Before(func() {
+				t.Setenv("CNB_TARGET_ARCH", "arm64")
+			})
+
+			it("uses the target architecture as the platform_machine", func() {
+				environment, err := process.Execute()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(environment.PlatformMachine).To(Equal("aarch64"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when python fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, err := fmt.Fprintln(execution.Stderr, "stderr output")
+						Expect(err).NotTo(HaveOccurred())
+						return errors.New("some-error")
+					}
+				})
+
+				it("returns an error", func() {
+					_, err := process.Execute()
+					Expect(err).To(MatchError("failed to determine the interpreter environment:\nstderr output\n\nerror: some-error"))
+				})
+			})
+
+			context("when the output is not JSON", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = nil
+				})
+
+				it("returns an error", func() {
+					_, err := process.Execute()
+					Expect(err).To(MatchError(ContainSubstring("failed to parse the interpreter environment")))
+				})
+			})
+		})
+	})
+}
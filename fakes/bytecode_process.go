@@ -0,0 +1,28 @@
+package fakes
+
+import "sync"
+
+type BytecodeProcess struct {
+	ExecuteCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			SitePackagesPath string
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(string) error
+	}
+}
+
+func (f *BytecodeProcess) Execute(param1 string) error {
+	f.ExecuteCall.mutex.Lock()
+	defer f.ExecuteCall.mutex.Unlock()
+	f.ExecuteCall.CallCount++
+	f.ExecuteCall.Receives.SitePackagesPath = param1
+	if f.ExecuteCall.Stub != nil {
+		return f.ExecuteCall.Stub(param1)
+	}
+	return f.ExecuteCall.Returns.Error
+}
@@ -0,0 +1,33 @@
+package fakes
+
+import (
+	"sync"
+
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+)
+
+type CacheProcess struct {
+	ExecuteCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			CacheDir string
+		}
+		Returns struct {
+			CacheUsage pipinstall.CacheUsage
+			Error      error
+		}
+		Stub func(string) (pipinstall.CacheUsage, error)
+	}
+}
+
+func (f *CacheProcess) Execute(param1 string) (pipinstall.CacheUsage, error) {
+	f.ExecuteCall.mutex.Lock()
+	defer f.ExecuteCall.mutex.Unlock()
+	f.ExecuteCall.CallCount++
+	f.ExecuteCall.Receives.CacheDir = param1
+	if f.ExecuteCall.Stub != nil {
+		return f.ExecuteCall.Stub(param1)
+	}
+	return f.ExecuteCall.Returns.CacheUsage, f.ExecuteCall.Returns.Error
+}
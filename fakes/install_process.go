@@ -1,32 +1,44 @@
 package fakes
 
-import "sync"
+import (
+	"context"
+	"sync"
+
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+)
 
 type InstallProcess struct {
 	ExecuteCall struct {
 		mutex     sync.Mutex
 		CallCount int
 		Receives  struct {
+			Ctx        context.Context
 			WorkingDir string
 			TargetDir  string
 			CacheDir   string
+			Packages   []string
+			Env        []string
 		}
 		Returns struct {
-			Error error
+			InstallResult pipinstall.InstallResult
+			Error         error
 		}
-		Stub func(string, string, string) error
+		Stub func(context.Context, string, string, string, []string, []string) (pipinstall.InstallResult, error)
 	}
 }
 
-func (f *InstallProcess) Execute(param1 string, param2 string, param3 string) error {
+func (f *InstallProcess) Execute(param1 context.Context, param2 string, param3 string, param4 string, param5 []string, param6 []string) (pipinstall.InstallResult, error) {
 	f.ExecuteCall.mutex.Lock()
 	defer f.ExecuteCall.mutex.Unlock()
 	f.ExecuteCall.CallCount++
-	f.ExecuteCall.Receives.WorkingDir = param1
-	f.ExecuteCall.Receives.TargetDir = param2
-	f.ExecuteCall.Receives.CacheDir = param3
+	f.ExecuteCall.Receives.Ctx = param1
+	f.ExecuteCall.Receives.WorkingDir = param2
+	f.ExecuteCall.Receives.TargetDir = param3
+	f.ExecuteCall.Receives.CacheDir = param4
+	f.ExecuteCall.Receives.Packages = param5
+	f.ExecuteCall.Receives.Env = param6
 	if f.ExecuteCall.Stub != nil {
-		return f.ExecuteCall.Stub(param1, param2, param3)
+		return f.ExecuteCall.Stub(param1, param2, param3, param4, param5, param6)
 	}
-	return f.ExecuteCall.Returns.Error
+	return f.ExecuteCall.Returns.InstallResult, f.ExecuteCall.Returns.Error
 }
@@ -0,0 +1,29 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+type MarkerEnvironmentProcess struct {
+	ExecuteCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Returns   struct {
+			Environment pep508.Environment
+			Error       error
+		}
+		Stub func() (pep508.Environment, error)
+	}
+}
+
+func (f *MarkerEnvironmentProcess) Execute() (pep508.Environment, error) {
+	f.ExecuteCall.mutex.Lock()
+	defer f.ExecuteCall.mutex.Unlock()
+	f.ExecuteCall.CallCount++
+	if f.ExecuteCall.Stub != nil {
+		return f.ExecuteCall.Stub()
+	}
+	return f.ExecuteCall.Returns.Environment, f.ExecuteCall.Returns.Error
+}
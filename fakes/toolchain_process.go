@@ -0,0 +1,29 @@
+package fakes
+
+import (
+	"sync"
+
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+)
+
+type ToolchainProcess struct {
+	ExecuteCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Returns   struct {
+			Toolchain pipinstall.Toolchain
+			Error     error
+		}
+		Stub func() (pipinstall.Toolchain, error)
+	}
+}
+
+func (f *ToolchainProcess) Execute() (pipinstall.Toolchain, error) {
+	f.ExecuteCall.mutex.Lock()
+	defer f.ExecuteCall.mutex.Unlock()
+	f.ExecuteCall.CallCount++
+	if f.ExecuteCall.Stub != nil {
+		return f.ExecuteCall.Stub()
+	}
+	return f.ExecuteCall.Returns.Toolchain, f.ExecuteCall.Returns.Error
+}
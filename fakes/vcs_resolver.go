@@ -0,0 +1,39 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+)
+
+type VCSResolver struct {
+	ResolveCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx        context.Context
+			Repository string
+			Ref        string
+			CacheDir   string
+		}
+		Returns struct {
+			Clone  string
+			Commit string
+			Err    error
+		}
+		Stub func(context.Context, string, string, string) (string, string, error)
+	}
+}
+
+func (f *VCSResolver) Resolve(param1 context.Context, param2 string, param3 string, param4 string) (string, string, error) {
+	f.ResolveCall.mutex.Lock()
+	defer f.ResolveCall.mutex.Unlock()
+	f.ResolveCall.CallCount++
+	f.ResolveCall.Receives.Ctx = param1
+	f.ResolveCall.Receives.Repository = param2
+	f.ResolveCall.Receives.Ref = param3
+	f.ResolveCall.Receives.CacheDir = param4
+	if f.ResolveCall.Stub != nil {
+		return f.ResolveCall.Stub(param1, param2, param3, param4)
+	}
+	return f.ResolveCall.Returns.Clone, f.ResolveCall.Returns.Commit, f.ResolveCall.Returns.Err
+}
@@ -0,0 +1,115 @@
+package pipinstall
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// GitResolver implements the VCSResolver interface by keeping mirror clones
+// of git repositories in the cache directory.
+type GitResolver struct {
+	executable Executable
+	logger     scribe.Emitter
+}
+
+// NewGitResolver creates an instance of the GitResolver given an Executable
+// that runs `git`.
+func NewGitResolver(executable Executable, logger scribe.Emitter) GitResolver {
+	return GitResolver{
+		executable: executable,
+		logger:     logger,
+	}
+}
+
+// Resolve returns the path of a mirror clone of the repository, cached under
+// cacheDir/vcs/git and keyed by the repository URL without its credentials,
+// along with the commit that ref resolves to. An existing clone is updated
+// before resolving the ref; if the update fails, for example in an offline
+// build, the cached clone is used as is. The clone only records the URL
+// without credentials, so the credentialed URL is passed to every fetch, and
+// git is stopped once the ctx is done.
+func (r GitResolver) Resolve(ctx context.Context, repository, ref, cacheDir string) (string, string, error) {
+	redacted := redactCredentials(repository)
+	sum := sha256.Sum256([]byte(redacted))
+	clone := filepath.Join(cacheDir, "vcs", "git", hex.EncodeToString(sum[:]))
+
+	exists, err := fs.Exists(clone)
+	if err != nil {
+		return "", "", err
+	}
+
+	if exists {
+		_, err = r.git(ctx, "--git-dir", clone, "fetch", "--quiet", "--prune", repository, "+refs/*:refs/*")
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", "", interrupted(ctx, fmt.Sprintf("updating the clone of '%s'", redacted), err)
+			}
+			r.logger.Subprocess("Warning: failed to update the cached clone, using it as is: %s", err)
+		}
+	} else {
+		err = os.MkdirAll(filepath.Dir(clone), os.ModePerm)
+		if err != nil {
+			return "", "", err
+		}
+
+		_, err = r.git(ctx, "clone", "--mirror", "--quiet", repository, clone)
+		if err == nil {
+			_, err = r.git(ctx, "--git-dir", clone, "remote", "set-url", "origin", redacted)
+		}
+		if err != nil {
+			os.RemoveAll(clone)
+			return "", "", interrupted(ctx, fmt.Sprintf("cloning '%s'", redacted), err)
+		}
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	commit, err := r.git(ctx, "--git-dir", clone, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil && fullCommitPattern.MatchString(ref) {
+		// Commits that are not reachable from any ref are not part of the
+		// mirror, but most servers allow fetching them directly.
+		_, err = r.git(ctx, "--git-dir", clone, "fetch", "--quiet", repository, ref)
+		if err == nil {
+			commit, err = r.git(ctx, "--git-dir", clone, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+		}
+	}
+	if err != nil {
+		return "", "", interrupted(ctx, fmt.Sprintf("resolving revision '%s'", ref), fmt.Errorf("revision '%s' not found: %w", ref, err))
+	}
+
+	return clone, commit, nil
+}
+
+// git runs git with the args, bound to the ctx. Its error output is stripped
+// of the credentials in the repository URLs it may quote.
+func (r GitResolver) git(ctx context.Context, args ...string) (string, error) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+
+	err := withContext(r.executable, ctx).Execute(pexec.Execution{
+		Args:   args,
+		Env:    append(os.Environ(), "GIT_TERMINAL_PROMPT=0"),
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, redactCredentials(message))
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
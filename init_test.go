@@ -11,7 +11,17 @@ func TestUnitPipInstall(t *testing.T) {
 	suite := spec.New("pipinstall", spec.Report(report.Terminal{}))
 	suite("Detect", testDetect)
 	suite("Build", testBuild)
+	suite("CacheProcess", testCacheProcess)
+	suite("CompileProcess", testCompileProcess)
+	suite("EnvironmentProcess", testEnvironmentProcess)
+	suite("GitResolver", testGitResolver)
 	suite("InstallProcess", testInstallProcess)
+	suite("ProcessGroupExecutable", testProcessGroupExecutable)
+	suite("PyProject", testPyProject)
+	suite("Requirements", testRequirements)
 	suite("SiteProcess", testSiteProcess)
+	suite("ToolchainProcess", testToolchainProcess)
+	suite("VCS", testVCS)
+	suite("VenvProcess", testVenvProcess)
 	suite.Run(t)
 }
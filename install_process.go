@@ -1,46 +1,164 @@
 package pipinstall
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/fs"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/pip-install/pep508"
 )
 
 //go:generate faux --interface Executable --output fakes/executable.go
+//go:generate faux --interface VCSResolver --output fakes/vcs_resolver.go
+//go:generate faux --interface MarkerEnvironmentProcess --output fakes/marker_environment_process.go
+//go:generate faux --interface ToolchainProcess --output fakes/toolchain_process.go
 
 // Executable defines the interface for invoking an executable.
 type Executable interface {
 	Execute(pexec.Execution) error
 }
 
+// VCSResolver defines the interface for resolving a revision of a VCS
+// repository to a commit in a local clone.
+type VCSResolver interface {
+	Resolve(ctx context.Context, repository, ref, cacheDir string) (clone, commit string, err error)
+}
+
+// MarkerEnvironmentProcess defines the interface for determining the
+// environment of the target interpreter, which requirement markers are
+// evaluated against and the pip cache is partitioned by.
+type MarkerEnvironmentProcess interface {
+	Execute() (pep508.Environment, error)
+}
+
+// ToolchainProcess defines the interface for determining the native
+// toolchain available to build distributions from source.
+type ToolchainProcess interface {
+	Execute() (Toolchain, error)
+}
+
+// InstallResult describes the outcome of an install.
+type InstallResult struct {
+	// IndexMirror is the mirror of BP_PIP_INDEX_MIRRORS the packages were
+	// installed from, if any, with its credentials removed.
+	IndexMirror string
+
+	// Direct and Transitive count the distributions pip installed because the
+	// requirements request them, and as their dependencies. They are drawn
+	// from the installation report of pip, and are zero when pip wrote none.
+	Direct     int
+	Transitive int
+
+	// Wheels counts the distributions installed from wheels, and
+	// SourceBuilds those built from source during the install. CacheHits
+	// counts the wheels that were reused from the cache layer, which are
+	// also counted as Wheels.
+	Wheels       int
+	SourceBuilds int
+	CacheHits    int
+
+	// LocalProjects and VCSRequirements count the local project and VCS
+	// requirements that were installed, whose origin is recorded in the
+	// direct_url.json of their distributions.
+	LocalProjects   int
+	VCSRequirements int
+
+	// ResolveDuration is the time pip spent resolving and downloading the
+	// distributions, BuildDuration the time spent building the wheels of
+	// sdists and local projects beforehand, and InstallDuration the time pip
+	// spent installing the distributions.
+	ResolveDuration time.Duration
+	BuildDuration   time.Duration
+	InstallDuration time.Duration
+}
+
 // PipInstallProcess implements the InstallProcess interface.
 type PipInstallProcess struct {
-	executable Executable
-	logger     scribe.Emitter
+	executable         Executable
+	vcsResolver        VCSResolver
+	environmentProcess MarkerEnvironmentProcess
+	toolchainProcess   ToolchainProcess
+	backoff            time.Duration
+	clock              chronos.Clock
+	logger             scribe.Emitter
 }
 
-// NewPipInstallProcess creates an instance of the PipInstallProcess given an Executable.
-func NewPipInstallProcess(executable Executable, logger scribe.Emitter) PipInstallProcess {
+// NewPipInstallProcess creates an instance of the PipInstallProcess given an
+// Executable that runs `pip`, a VCSResolver for VCS requirements, a
+// MarkerEnvironmentProcess that describes the target interpreter and a
+// ToolchainProcess for checking requirements built from source.
+func NewPipInstallProcess(executable Executable, vcsResolver VCSResolver, environmentProcess MarkerEnvironmentProcess, toolchainProcess ToolchainProcess, logger scribe.Emitter) PipInstallProcess {
 	return PipInstallProcess{
-		executable: executable,
-		logger:     logger,
+		executable:         executable,
+		vcsResolver:        vcsResolver,
+		environmentProcess: environmentProcess,
+		toolchainProcess:   toolchainProcess,
+		backoff:            time.Second,
+		clock:              chronos.DefaultClock,
+		logger:             logger,
 	}
 }
 
-// Execute installs the pip dependencies from workingDir/requirements.txt into
-// the targetPath. The cachePath is used for the pip cache directory.
+// WithRetryBackoff returns a copy of the process that waits for the given
+// duration before retrying a failed install for the first time, instead of
+// one second.
+func (p PipInstallProcess) WithRetryBackoff(backoff time.Duration) PipInstallProcess {
+	p.backoff = backoff
+	return p
+}
+
+// WithClock returns a copy of the process that times the phases of the
+// install with the given clock.
+func (p PipInstallProcess) WithClock(clock chronos.Clock) PipInstallProcess {
+	p.clock = clock
+	return p
+}
+
+// Execute installs the pip dependencies from workingDir/requirements.txt,
+// along with the packages requested by other buildpacks, into the
+// targetPath. The cachePath is used for the pip cache directory, built wheels
+// and cloned VCS repositories.
 //
-// The pip install command will install from local packages if they are found at
-// the directory specified by `BP_PIP_DEST_PATH`, which defaults to `vendor`.
-func (p PipInstallProcess) Execute(workingDir, targetPath, cachePath string) error {
+// The pip processes are bound to the ctx and given the env in addition to
+// the environment of the buildpack. The install is configured through the
+// environment variables described in the README.
+func (p PipInstallProcess) Execute(ctx context.Context, workingDir, targetPath, cachePath string, packages, env []string) (InstallResult, error) {
+	p.executable = withContext(p.executable, ctx)
+
+	projectRequirements, hasPyProject, err := p.pyProjectRequirements(workingDir)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	if len(packages) > 0 {
+		p.logger.Subprocess("Installing packages requested by buildpacks: %s", redactCredentials(strings.Join(packages, ", ")))
+		for _, specifier := range packages {
+			projectRequirements = append(projectRequirements, RequirementLine{File: buildPlanFile, Requirement: specifier})
+		}
+	}
+
 	requirements, exists := os.LookupEnv("BP_PIP_REQUIREMENT")
 	if !exists {
 		requirements = "requirements.txt"
+
+		if hasPyProject {
+			found, err := fs.Exists(filepath.Join(workingDir, requirements))
+			if err != nil {
+				return InstallResult{}, err
+			}
+			if !found {
+				requirements = ""
+			}
+		}
 	}
 
 	vendorDir := filepath.Join(workingDir, "vendor")
@@ -53,66 +171,444 @@ func (p PipInstallProcess) Execute(workingDir, targetPath, cachePath string) err
 
 	combinedFindLinks := []string{userFindLinks, findLinks}
 
-	var args []string
-	if exists, err := fs.Exists(vendorDir); err != nil {
-		return err
-	} else if exists {
+	offline, err := fs.Exists(vendorDir)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	if offline {
 		combinedFindLinks = append(combinedFindLinks, vendorDir)
-		args = offlineArgs(requirements)
+	}
+
+	lines, err := ParseRequirements(workingDir, strings.Fields(requirements))
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	environment, err := p.environmentProcess.Execute()
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	pipCachePath, err := p.pipCachePartition(cachePath, environment)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	indexArgs := []string{fmt.Sprintf("--cache-dir=%s", pipCachePath)}
+	if offline {
+		indexArgs = []string{"--no-index"}
+	}
+
+	mode, err := compileMode()
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	binaryOnly, allowedSdists, err := binaryOnlyPolicy()
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	retries, err := installRetries()
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	layout, err := installLayout()
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	urls, err := indexMirrors()
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	// Mirrors only apply to online installs.
+	mirrors := &mirrorList{}
+	if !offline {
+		mirrors.urls = urls
+	}
+
+	localDirs := localFindLinks(workingDir, strings.Fields(userFindLinks))
+	if offline {
+		localDirs = append(localDirs, vendorDir)
+	}
+
+	// Under the binary-only policy, only the sdists of the allowed projects
+	// are built, by pip itself: it is given --no-binary for them, and would
+	// not install wheels built from their sdists beforehand.
+	var sdists []string
+	for _, sdist := range localSdists(localDirs) {
+		if sdistAllowed(sdistName(sdist), binaryOnly, allowedSdists) {
+			sdists = append(sdists, sdist)
+		}
+	}
+
+	lines = p.activeRequirements(lines, environment)
+	projectRequirements = p.activeRequirements(projectRequirements, environment)
+
+	active := append(append([]RequirementLine{}, lines...), projectRequirements...)
+
+	p.reportConflicts(active)
+
+	// Only the sdists of the projects that the active requirements name are
+	// built beforehand. Those of other projects, such as transitive
+	// dependencies, are left to pip, which builds them if it needs them.
+	var prebuilt []string
+	if !binaryOnly {
+		prebuilt = requestedSdists(sdists, active)
+	}
+
+	policy, transitive, err := requirePinnedPolicy()
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	var requested map[string]bool
+	if policy != "" {
+		requested, err = p.checkPinned(workingDir, active, policy)
+		if err != nil {
+			return InstallResult{}, err
+		}
+	}
+
+	var builds []sourceBuild
+	candidates, err := sourceBuilds(cachePath, environment, active, sdists, localDirs)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	for _, build := range candidates {
+		if sdistAllowed(build.Name, binaryOnly, allowedSdists) {
+			builds = append(builds, build)
+		}
+	}
+
+	if len(builds) > 0 {
+		err = p.checkToolchain(builds)
+		if err != nil {
+			return InstallResult{}, err
+		}
+	}
+
+	var result InstallResult
+
+	buildStart := p.clock.Now()
+	builtWheelDirs, cachedWheelDirs, err := p.builtWheels(ctx, workingDir, cachePath, environment, prebuilt, indexArgs, mirrors.Env(append(pipEnvironment(targetPath, combinedFindLinks), env...)))
+	if err != nil {
+		return InstallResult{}, err
+	}
+	result.BuildDuration = p.clock.Now().Sub(buildStart)
+
+	origins := wheelOrigins{cached: cachedWheelDirs}
+	for _, dir := range builtWheelDirs {
+		if !cachedWheelDirs[dir] {
+			origins.built = append(origins.built, dir)
+		}
+	}
+
+	env = append(pipEnvironment(targetPath, append(combinedFindLinks, builtWheelDirs...)), env...)
+
+	tmpDir, err := os.MkdirTemp("", "pip-install")
+	if err != nil {
+		return InstallResult{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	all := append(append([]RequirementLine{}, lines...), projectRequirements...)
+
+	var projects []localProject
+	if hasLocalProjects(workingDir, all) {
+		buildStart = p.clock.Now()
+		origins.built = append(origins.built, tmpDir)
+
+		var built []localProject
+		lines, projects, err = p.buildLocalProjects(ctx, workingDir, filepath.Join(tmpDir, "wheels"), lines, indexArgs, env, mirrors)
+		if err != nil {
+			return InstallResult{}, err
+		}
+
+		projectRequirements, built, err = p.buildLocalProjects(ctx, workingDir, filepath.Join(tmpDir, "project-wheels"), projectRequirements, indexArgs, env, mirrors)
+		if err != nil {
+			return InstallResult{}, err
+		}
+		projects = append(projects, built...)
+		result.BuildDuration += p.clock.Now().Sub(buildStart)
+	}
+
+	var vcsRequirements []vcsRequirement
+	if hasVCSRequirements(all) {
+		var resolved []vcsRequirement
+		lines, vcsRequirements, err = p.resolveVCSRequirements(ctx, lines, cachePath)
+		if err != nil {
+			return InstallResult{}, err
+		}
+
+		projectRequirements, resolved, err = p.resolveVCSRequirements(ctx, projectRequirements, cachePath)
+		if err != nil {
+			return InstallResult{}, err
+		}
+		vcsRequirements = append(vcsRequirements, resolved...)
+	}
+
+	// Requirements that were rewritten are installed from a single
+	// requirements file that has the nested requirements files inlined.
+	if len(projects) > 0 || len(vcsRequirements) > 0 {
+		requirements = ""
+		if len(lines) > 0 {
+			requirements = filepath.Join(tmpDir, "requirements.txt")
+			err = WriteRequirements(requirements, lines)
+			if err != nil {
+				return InstallResult{}, err
+			}
+		}
+	}
+
+	var args []string
+	if offline {
+		args = offlineArgs(requirements, mode == "serial", formatArgs(binaryOnly, allowedSdists))
 	} else {
-		args = onlineArgs(cachePath, requirements)
+		args = onlineArgs(pipCachePath, requirements, mode == "serial", formatArgs(binaryOnly, allowedSdists))
+	}
+	args = withLayout(args, layout, targetPath)
+	if layout == "target" {
+		err = clearLayer(targetPath)
+		if err != nil {
+			return InstallResult{}, fmt.Errorf("failed to clear the packages layer: %w", err)
+		}
+	}
+	for _, line := range projectRequirements {
+		args = append(args, line.String())
 	}
 
-	p.logger.Subprocess("Running 'pip %s'", strings.Join(args, " "))
+	p.logger.Subprocess("Running 'pip %s'", redactCredentials(strings.Join(args, " ")))
 
-	err := p.executable.Execute(pexec.Execution{
-		Args: args,
-		Env: append(os.Environ(),
-			fmt.Sprintf("PYTHONUSERBASE=%s", targetPath),
-			fmt.Sprintf("PIP_FIND_LINKS=%s", strings.TrimLeft(strings.Join(combinedFindLinks, " "), " ")),
-		),
-		Dir:    workingDir,
-		Stdout: p.logger.ActionWriter,
-		Stderr: p.logger.ActionWriter,
+	// pip describes what it installed in a report, which the summary of the
+	// install is drawn from.
+	report := filepath.Join(tmpDir, "report.json")
+
+	output := bytes.NewBuffer(nil)
+	err = p.failover(ctx, mirrors, func() (string, error) {
+		return p.retry(ctx, retries, func() (string, error) {
+			output.Reset()
+			start := p.clock.Now()
+			err := p.executable.Execute(pexec.Execution{
+				Args:   args,
+				Env:    append(append([]string{}, mirrors.Env(env)...), fmt.Sprintf("PIP_REPORT=%s", report)),
+				Dir:    workingDir,
+				Stdout: p.logger.ActionWriter,
+				Stderr: io.MultiWriter(p.logger.ActionWriter, output),
+			})
+			result.ResolveDuration, result.InstallDuration = reportDurations(report, start, p.clock.Now())
+			return output.String(), err
+		})
 	})
 	if err != nil {
-		return fmt.Errorf("pip install failed:\nerror: %w", err)
+		if missing := missingWheels(output.String()); binaryOnly && len(missing) > 0 {
+			return InstallResult{}, interrupted(ctx, "pip install", fmt.Errorf("no compatible wheel found for '%s' (BP_PIP_BINARY_ONLY=true): publish or vendor wheels for them, or allow building them from source with BP_PIP_ALLOW_SDIST\nerror: %w", strings.Join(missing, "', '"), err))
+		}
+		return InstallResult{}, interrupted(ctx, "pip install", fmt.Errorf("pip install failed:\nerror: %w", err))
+	}
+
+	if mirrors.URL() != "" {
+		p.logger.Subprocess("Installed from index mirror '%s'", mirrors.Redacted())
+	}
+	result.IndexMirror = mirrors.Redacted()
+
+	err = readInstallReport(report, origins, &result)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	if layout == "target" {
+		err = relocateTargetData(targetPath)
+		if err != nil {
+			return InstallResult{}, fmt.Errorf("failed to relocate scripts and data files: %w", err)
+		}
+	}
+
+	result.LocalProjects = len(projects)
+	if len(projects) > 0 {
+		err = recordLocalProjects(targetPath, projects)
+		if err != nil {
+			return InstallResult{}, fmt.Errorf("failed to record local requirements: %w", err)
+		}
+	}
+
+	result.VCSRequirements = len(vcsRequirements)
+	if len(vcsRequirements) > 0 {
+		err = recordVCSRequirements(targetPath, vcsRequirements)
+		if err != nil {
+			return InstallResult{}, fmt.Errorf("failed to record VCS requirements: %w", err)
+		}
+	}
+
+	if policy != "" && transitive {
+		err = p.checkTransitivePinned(targetPath, requested, policy)
+		if err != nil {
+			return InstallResult{}, err
+		}
+	}
+
+	return result, nil
+}
+
+// pipEnvironment returns the environment of the pip processes, which install
+// into targetPath and find distributions in the findLinks directories.
+func pipEnvironment(targetPath string, findLinks []string) []string {
+	return append(os.Environ(),
+		fmt.Sprintf("PYTHONUSERBASE=%s", targetPath),
+		fmt.Sprintf("PIP_FIND_LINKS=%s", strings.TrimLeft(strings.Join(findLinks, " "), " ")),
+	)
+}
+
+// pyProjectRequirements returns the requirements declared in the PEP 621
+// pyproject.toml of the workingDir when BP_PIP_PYPROJECT is enabled, and
+// whether the project declares its dependencies statically, in which case it
+// does not need a requirements file.
+func (p PipInstallProcess) pyProjectRequirements(workingDir string) ([]RequirementLine, bool, error) {
+	enabled, err := pyProjectEnabled()
+	if err != nil || !enabled {
+		return nil, false, err
+	}
+
+	path := filepath.Join(workingDir, "pyproject.toml")
+	if exists, err := fs.Exists(path); err != nil || !exists {
+		return nil, false, err
+	}
+
+	// As during detection, a pyproject.toml that cannot be parsed leaves
+	// the requirements files to install from.
+	pyProject, err := ParsePyProject(path)
+	if err != nil {
+		p.logger.Subprocess("Warning: %s, installing from the requirements files only", err)
+		return nil, false, nil
+	}
+
+	if !pyProject.Defined {
+		return nil, false, nil
+	}
+
+	// Dynamic dependencies are only known to the build backend, and are
+	// typically read from the requirements files, which are installed
+	// instead.
+	dynamic := pyProject.IsDynamic("dependencies")
+	if dynamic {
+		p.logger.Subprocess("Warning: pyproject.toml declares its dependencies as dynamic, so they are not installed from it; list them in a requirements file")
+	}
+
+	requirements, err := pyProject.Requirements(pyProjectExtras())
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !dynamic || len(requirements) > 0 {
+		p.logger.Subprocess("Installing %d dependencies declared in pyproject.toml", len(requirements))
+	}
+
+	var lines []RequirementLine
+	for _, requirement := range requirements {
+		lines = append(lines, RequirementLine{
+			File:        "pyproject.toml",
+			Requirement: requirement,
+		})
+	}
+
+	return lines, !dynamic, nil
+}
+
+// activeRequirements returns the lines without those whose environment
+// markers do not match the environment, which are logged as skipped. Markers
+// that cannot be parsed or evaluated are logged as well, and their lines are
+// left to pip.
+func (p PipInstallProcess) activeRequirements(lines []RequirementLine, environment pep508.Environment) []RequirementLine {
+	var active []RequirementLine
+	for _, line := range lines {
+		marker, ok, err := line.Marker()
+		if err != nil {
+			p.logger.Subprocess("Warning: failed to parse the marker of '%s', leaving it to pip: %s", redactCredentials(line.Requirement), err)
+		}
+
+		if ok {
+			matches, err := marker.Evaluate(environment, "")
+			if err != nil {
+				p.logger.Subprocess("Warning: %s: failed to evaluate marker '%s', leaving it to pip: %s", line.Location(), marker, err)
+				matches = true
+			}
+
+			if !matches {
+				p.logger.Subprocess("Skipping '%s' (%s): marker '%s' does not match the target interpreter", redactCredentials(line.Requirement), line.Location(), marker)
+				continue
+			}
+		}
+
+		active = append(active, line)
 	}
 
-	return nil
+	return active
+}
+
+func hasLocalProjects(workingDir string, lines []RequirementLine) bool {
+	for _, line := range lines {
+		if _, ok := line.LocalProject(workingDir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVCSRequirements(lines []RequirementLine) bool {
+	for _, line := range lines {
+		if _, ok := ParseVCSRequirement(line.Requirement); ok {
+			return true
+		}
+	}
+	return false
 }
 
 func parseAppendArgs(key string, values string) []string {
 	var rv []string
-	for _, val := range strings.Split(values, " ") {
+	for _, val := range strings.Fields(values) {
 		rv = append(rv, fmt.Sprintf("--%s=%s", key, val))
 	}
 	return rv
 }
 
-func onlineArgs(cachePath string, requirements string) []string {
+// compileArg returns the pip option that controls whether pip byte-compiles
+// the packages it installs.
+func compileArg(compile bool) string {
+	if compile {
+		return "--compile"
+	}
+	return "--no-compile"
+}
+
+func onlineArgs(cachePath string, requirements string, compile bool, formatArgs []string) []string {
 	rv := []string{
 		"install",
 		"--exists-action=w",
 		fmt.Sprintf("--cache-dir=%s", cachePath),
-		"--compile",
+		compileArg(compile),
 		"--user",
 		"--disable-pip-version-check",
 	}
+	rv = append(rv, formatArgs...)
 	rv = append(rv, parseAppendArgs("requirement", requirements)...)
 	return rv
 }
 
-func offlineArgs(requirements string) []string {
+func offlineArgs(requirements string, compile bool, formatArgs []string) []string {
 	rv := []string{
 		"install",
 		"--ignore-installed",
 		"--exists-action=w",
 		"--no-index",
-		"--compile",
+		compileArg(compile),
 		"--user",
 		"--disable-pip-version-check",
 	}
+	rv = append(rv, formatArgs...)
 	rv = append(rv, parseAppendArgs("requirement", requirements)...)
 	return rv
 }
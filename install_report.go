@@ -0,0 +1,105 @@
+package pipinstall
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// installReport is the part of the installation report, which pip writes to
+// the path given in --report (or PIP_REPORT), that describes the installed
+// distributions.
+type installReport struct {
+	Install []struct {
+		Requested    bool `json:"requested"`
+		DownloadInfo struct {
+			URL string `json:"url"`
+		} `json:"download_info"`
+	} `json:"install"`
+}
+
+// wheelOrigins tells where the wheels the buildpack offers to pip come from:
+// the directories of built wheels that were reused from the cache layer, and
+// the directories of the wheels built from source during this install.
+type wheelOrigins struct {
+	cached map[string]bool
+	built  []string
+}
+
+// readInstallReport counts the distributions listed in the installation
+// report at path into the result: those requested by the requirements or
+// installed as their dependencies, and those installed from wheels or built
+// from source, either by pip or by the buildpack beforehand. The wheels
+// reused from the cache layer count as cache hits. A missing report, which
+// pip versions older than 22.2 do not write, leaves the result untouched.
+func readInstallReport(path string, origins wheelOrigins, result *InstallResult) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var report installReport
+	err = json.Unmarshal(content, &report)
+	if err != nil {
+		return fmt.Errorf("failed to parse pip installation report: %w", err)
+	}
+
+	for _, install := range report.Install {
+		if install.Requested {
+			result.Direct++
+		} else {
+			result.Transitive++
+		}
+
+		location, err := url.Parse(install.DownloadInfo.URL)
+		if err != nil || !strings.HasSuffix(location.Path, ".whl") {
+			result.SourceBuilds++
+			continue
+		}
+
+		dir := filepath.Dir(location.Path)
+		switch {
+		case location.Scheme == "file" && origins.cached[dir]:
+			result.Wheels++
+			result.CacheHits++
+		case location.Scheme == "file" && origins.builtBy(dir):
+			result.SourceBuilds++
+		default:
+			result.Wheels++
+		}
+	}
+
+	return nil
+}
+
+func (o wheelOrigins) builtBy(dir string) bool {
+	for _, built := range o.built {
+		if dir == built || strings.HasPrefix(dir, built+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportDurations splits the time pip install ran, from start to end, into
+// the time spent resolving and downloading, and the time spent installing.
+// pip writes the installation report at path once it has resolved the
+// distributions, right before it installs them, so the report is last
+// modified where the phases meet. Without a report written during the run,
+// all of it counts as resolving.
+func reportDurations(path string, start, end time.Time) (time.Duration, time.Duration) {
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().Before(start) || info.ModTime().After(end) {
+		return end.Sub(start), 0
+	}
+
+	return info.ModTime().Sub(start), end.Sub(info.ModTime())
+}
@@ -116,6 +116,7 @@ func TestIntegration(t *testing.T) {
 	suite := spec.New("Integration", spec.Report(report.Terminal{}))
 	suite("Default", testDefault, spec.Parallel())
 	suite("Offline", testOffline, spec.Parallel())
+	suite("PyProject", testPyProject, spec.Parallel())
 	suite("Reused", testReused, spec.Parallel())
 	suite.Run(t)
 }
@@ -0,0 +1,203 @@
+package pipinstall
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// InventoryFile is the name of the inventory file written to the packages
+// layer.
+const InventoryFile = "inventory.json"
+
+// InventoryVersion is the version of the format of the inventory file. It
+// changes only when a field is removed or its meaning changes.
+const InventoryVersion = 1
+
+// Inventory is the content of the inventory file, which lists the packages
+// installed in the packages layer for the buildpacks that run later.
+type Inventory struct {
+	// Version is the InventoryVersion of the file.
+	Version int `json:"version"`
+
+	// Packages are the installed distributions, sorted by their normalized
+	// name.
+	Packages []InventoryPackage `json:"packages"`
+}
+
+// InventoryPackage is an installed distribution in the inventory file.
+type InventoryPackage struct {
+	// Name and Version are taken from the METADATA of the distribution.
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// NormalizedName is the name normalized as per PEP 503, such as
+	// "zope-interface" for "zope.interface".
+	NormalizedName string `json:"normalized_name"`
+
+	// TopLevelModules are the importable top-level modules and packages of
+	// the distribution, sorted.
+	TopLevelModules []string `json:"top_level_modules"`
+
+	// ConsoleScripts are the names of the console scripts the distribution
+	// installs, sorted.
+	ConsoleScripts []string `json:"console_scripts"`
+}
+
+// writeInventory writes the inventory of the distributions installed in the
+// layer to the InventoryFile at the root of the layer, and returns its path
+// along with the number of packages it lists.
+func writeInventory(layerPath string) (string, int, error) {
+	distributions, err := installedDistributions(layerPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list installed distributions: %w", err)
+	}
+
+	inventory := Inventory{
+		Version:  InventoryVersion,
+		Packages: []InventoryPackage{},
+	}
+
+	for _, dist := range distributions {
+		modules, err := topLevelModules(dist.Path)
+		if err != nil {
+			return "", 0, err
+		}
+
+		scripts, err := consoleScripts(dist.Path)
+		if err != nil {
+			return "", 0, err
+		}
+
+		inventory.Packages = append(inventory.Packages, InventoryPackage{
+			Name:            dist.Name,
+			Version:         dist.Version,
+			NormalizedName:  NormalizeName(dist.Name),
+			TopLevelModules: modules,
+			ConsoleScripts:  scripts,
+		})
+	}
+
+	content, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return "", 0, err
+	}
+
+	path := filepath.Join(layerPath, InventoryFile)
+	err = os.WriteFile(path, append(content, '\n'), 0644)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write package inventory: %w", err)
+	}
+
+	return path, len(inventory.Packages), nil
+}
+
+// topLevelModules returns the top-level modules of the distribution whose
+// .dist-info directory is distInfo, as listed in its top_level.txt or, when
+// it has none, as found in its RECORD.
+func topLevelModules(distInfo string) ([]string, error) {
+	modules := []string{}
+	seen := map[string]bool{}
+	add := func(module string) {
+		if module != "" && !seen[module] {
+			seen[module] = true
+			modules = append(modules, module)
+		}
+	}
+
+	lines, err := readLines(filepath.Join(distInfo, "top_level.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	if lines == nil {
+		lines, err = readLines(filepath.Join(distInfo, "RECORD"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range lines {
+			path, _, _ := strings.Cut(line, ",")
+			first, rest, nested := strings.Cut(filepath.ToSlash(path), "/")
+
+			switch {
+			case first == "" || strings.HasPrefix(first, ".") || first == "__pycache__":
+			case strings.HasSuffix(first, ".dist-info") || strings.HasSuffix(first, ".data"):
+			case nested && rest != "":
+				add(first)
+			case strings.HasSuffix(first, ".py"):
+				add(strings.TrimSuffix(first, ".py"))
+			case strings.HasSuffix(first, ".so"):
+				module, _, _ := strings.Cut(first, ".")
+				add(module)
+			}
+		}
+	} else {
+		for _, line := range lines {
+			add(strings.TrimSpace(line))
+		}
+	}
+
+	sort.Strings(modules)
+	return modules, nil
+}
+
+// consoleScripts returns the names of the console scripts declared in the
+// entry_points.txt of the distribution whose .dist-info directory is
+// distInfo.
+func consoleScripts(distInfo string) ([]string, error) {
+	lines, err := readLines(filepath.Join(distInfo, "entry_points.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := []string{}
+	var section string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		if section != "console_scripts" || line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		name, _, found := strings.Cut(line, "=")
+		if found {
+			scripts = append(scripts, strings.TrimSpace(name))
+		}
+	}
+
+	sort.Strings(scripts)
+	return scripts, nil
+}
+
+// readLines returns the lines of the file, or nil when it does not exist.
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return lines, nil
+}
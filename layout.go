@@ -0,0 +1,188 @@
+package pipinstall
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// installLayout returns how the packages are laid out in the packages
+// layer, given in BP_PIP_INSTALL_LAYOUT: "user" (the default) installs them
+// with --user into the layer as the user base, "venv" installs them into a
+// virtual environment created at the root of the layer, and "target"
+// installs them with --target into a site-packages directory at a path of
+// the layer that does not depend on the interpreter version.
+func installLayout() (string, error) {
+	layout, exists := os.LookupEnv("BP_PIP_INSTALL_LAYOUT")
+	if !exists {
+		return "user", nil
+	}
+
+	switch layout {
+	case "user", "venv", "target":
+		return layout, nil
+	}
+
+	return "", fmt.Errorf("invalid BP_PIP_INSTALL_LAYOUT value '%s': must be one of 'user', 'venv' or 'target'", layout)
+}
+
+// withLayout returns the pip install args, which install with --user,
+// adapted to install into the layer at targetPath with the layout. In the
+// venv layout, pip runs with the interpreter of the virtual environment
+// through --python, a general option that pip only accepts before the
+// command. In the target layout, --user is replaced by --target.
+func withLayout(args []string, layout, targetPath string) []string {
+	var rv []string
+	switch layout {
+	case "venv":
+		rv = append(rv, fmt.Sprintf("--python=%s", venvPython(targetPath)))
+	case "target":
+	default:
+		return args
+	}
+
+	for _, arg := range args {
+		switch {
+		case arg != "--user":
+			rv = append(rv, arg)
+		case layout == "target":
+			rv = append(rv, fmt.Sprintf("--target=%s", targetSitePackages(targetPath)))
+		}
+	}
+	return rv
+}
+
+// venvPython returns the path of the interpreter of the virtual environment
+// at path.
+func venvPython(path string) string {
+	return filepath.Join(path, "bin", "python")
+}
+
+// targetSitePackages returns the path of the site-packages directory of the
+// target layout in the layer at path.
+func targetSitePackages(path string) string {
+	return filepath.Join(path, "site-packages")
+}
+
+// clearLayer removes the contents of the layer at path. pip --target
+// installs every package anew, and only replaces the directories of the
+// packages it installs, so the target layout starts from an empty layer to
+// drop the packages that are no longer required.
+func clearLayer(path string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		err = os.RemoveAll(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// relocateTargetData moves the scripts and data files that pip --target
+// leaves in the site-packages directory of the layer at path, such as bin/
+// or share/, to the root of the layer, where they would be in a prefix, so
+// that they are not mistaken for packages. pip lists them in the RECORD of
+// their distribution relative to the lib/python directory of the
+// installation it made, which is rewritten to point at their new location.
+func relocateTargetData(path string) error {
+	sitePackages := targetSitePackages(path)
+
+	records, err := filepath.Glob(filepath.Join(sitePackages, "*.dist-info", "RECORD"))
+	if err != nil {
+		return err
+	}
+
+	data := map[string]bool{}
+	for _, record := range records {
+		entries, err := readRecord(record)
+		if err != nil {
+			return err
+		}
+
+		changed := false
+		for _, entry := range entries {
+			rest, found := strings.CutPrefix(entry[0], "../../")
+			if !found {
+				continue
+			}
+
+			name, _, _ := strings.Cut(rest, "/")
+			data[name] = true
+			entry[0] = "../" + rest
+			changed = true
+		}
+
+		if changed {
+			err = writeRecord(record, entries)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for name := range data {
+		source := filepath.Join(sitePackages, name)
+		if _, err := os.Lstat(source); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		err = os.RemoveAll(filepath.Join(path, name))
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(source, filepath.Join(path, name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readRecord(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	entries, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return entries, nil
+}
+
+func writeRecord(path string, entries [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	err = writer.WriteAll(entries)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
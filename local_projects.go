@@ -0,0 +1,169 @@
+package pipinstall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// localProject is a project directory referenced by a requirement, such as
+// "-e ." or "./libs/shared", that is built into a wheel before installation.
+type localProject struct {
+	// Line is the requirement that refers to the project.
+	Line RequirementLine
+
+	// Path is the absolute path of the project directory.
+	Path string
+
+	// Wheel is the absolute path of the wheel built from the project.
+	Wheel string
+}
+
+var extrasPattern = regexp.MustCompile(`\[[^\]]*\]`)
+
+// buildLocalProjects builds a wheel into wheelDir for each requirement that
+// refers to a local project directory and returns the lines with those
+// requirements replaced by direct references to the wheels. Editable
+// requirements are built as regular wheels, so that the installed packages
+// do not refer back into the working directory.
+func (p PipInstallProcess) buildLocalProjects(ctx context.Context, workingDir, wheelDir string, lines []RequirementLine, indexArgs, env []string, mirrors *mirrorList) ([]RequirementLine, []localProject, error) {
+	var (
+		rewritten []RequirementLine
+		projects  []localProject
+	)
+
+	for _, line := range lines {
+		path, ok := line.LocalProject(workingDir)
+		if !ok {
+			rewritten = append(rewritten, line)
+			continue
+		}
+
+		dir := filepath.Join(wheelDir, fmt.Sprintf("%d", len(projects)))
+		args := append([]string{
+			"wheel",
+			"--no-deps",
+			fmt.Sprintf("--wheel-dir=%s", dir),
+		}, indexArgs...)
+		args = append(args, "--disable-pip-version-check", path)
+
+		p.logger.Subprocess("Building local requirement '%s' (%s) as a wheel", line.String(), line.Location())
+		p.logger.Subprocess("Running 'pip %s'", strings.Join(args, " "))
+
+		// The build dependencies of the project are installed from the index.
+		err := p.failover(ctx, mirrors, func() (string, error) {
+			output := bytes.NewBuffer(nil)
+			err := p.executable.Execute(pexec.Execution{
+				Args:   args,
+				Env:    mirrors.Env(env),
+				Dir:    workingDir,
+				Stdout: p.logger.ActionWriter,
+				Stderr: io.MultiWriter(p.logger.ActionWriter, output),
+			})
+			return output.String(), err
+		})
+		if err != nil {
+			return nil, nil, interrupted(ctx, fmt.Sprintf("building local requirement '%s' (%s)", line.String(), line.Location()), fmt.Errorf("failed to build local requirement '%s' (%s):\nerror: %w", line.String(), line.Location(), err))
+		}
+
+		wheels, err := filepath.Glob(filepath.Join(dir, "*.whl"))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(wheels) != 1 {
+			return nil, nil, fmt.Errorf("failed to build local requirement '%s' (%s): expected a single wheel, found %d", line.String(), line.Location(), len(wheels))
+		}
+
+		location, marker, _ := strings.Cut(line.Requirement, ";")
+		name, _, _ := strings.Cut(filepath.Base(wheels[0]), "-")
+
+		requirement := fmt.Sprintf("%s%s @ %s", name, extrasPattern.FindString(location), (&url.URL{Scheme: "file", Path: wheels[0]}).String())
+		if marker = strings.TrimSpace(marker); marker != "" {
+			requirement = fmt.Sprintf("%s ; %s", requirement, marker)
+		}
+
+		rewritten = append(rewritten, RequirementLine{
+			File:        line.File,
+			Line:        line.Line,
+			Requirement: requirement,
+			Options:     line.Options,
+		})
+		projects = append(projects, localProject{
+			Line:  line,
+			Path:  path,
+			Wheel: wheels[0],
+		})
+	}
+
+	return rewritten, projects, nil
+}
+
+// recordLocalProjects rewrites the direct_url.json of the distributions
+// installed from the wheels of local projects, so that they record the
+// project directory they were built from rather than the transient wheel.
+func recordLocalProjects(targetPath string, projects []localProject) error {
+	origins := map[string]string{}
+	for _, project := range projects {
+		origins[(&url.URL{Scheme: "file", Path: project.Wheel}).String()] = (&url.URL{Scheme: "file", Path: project.Path}).String()
+	}
+
+	return rewriteDirectURLs(targetPath, func(directURL map[string]interface{}) bool {
+		location, _ := directURL["url"].(string)
+		origin, ok := origins[location]
+		if !ok {
+			return false
+		}
+
+		delete(directURL, "archive_info")
+		directURL["url"] = origin
+		directURL["dir_info"] = map[string]interface{}{}
+
+		return true
+	})
+}
+
+// rewriteDirectURLs calls rewrite with the contents of every direct_url.json
+// of the distributions installed in targetPath, and writes the contents back
+// when rewrite reports that it changed them.
+func rewriteDirectURLs(targetPath string, rewrite func(directURL map[string]interface{}) bool) error {
+	return filepath.WalkDir(targetPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || entry.Name() != "direct_url.json" || !strings.HasSuffix(filepath.Dir(path), ".dist-info") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var directURL map[string]interface{}
+		if err := json.Unmarshal(content, &directURL); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		if !rewrite(directURL) {
+			return nil
+		}
+
+		content, err = json.Marshal(directURL)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(path, content, 0644)
+	})
+}
@@ -0,0 +1,110 @@
+package pipinstall
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// networkPattern matches the messages with which pip reports that it failed
+// to reach an index, which includes the transient failures as well as
+// refused connections and names that do not resolve.
+var networkPattern = regexp.MustCompile(transientPattern.String() + `|(?i)Connection refused|Failed to establish a new connection|NameResolutionError|Temporary failure in name resolution|Name or service not known|Max retries exceeded with url`)
+
+// indexMirrors returns the index URLs given in BP_PIP_INDEX_MIRRORS, as a
+// list separated by commas or whitespace, in the order they are tried.
+func indexMirrors() ([]string, error) {
+	value := os.Getenv("BP_PIP_INDEX_MIRRORS")
+
+	var mirrors []string
+	for _, mirror := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' }) {
+		u, err := url.Parse(mirror)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse BP_PIP_INDEX_MIRRORS value '%s': %s", redactCredentials(value), redactCredentials(err.Error()))
+		}
+
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("failed to parse BP_PIP_INDEX_MIRRORS value '%s': '%s' is not an http or https URL", redactCredentials(value), redactCredentials(mirror))
+		}
+
+		mirrors = append(mirrors, mirror)
+	}
+
+	return mirrors, nil
+}
+
+// networkFailure returns the reason of a failure that pip reported in its
+// output, and reports whether pip failed to reach the index.
+func networkFailure(output string) (string, bool) {
+	reason := networkPattern.FindString(output)
+	return reason, reason != ""
+}
+
+// mirrorList is the list of index mirrors of an install, which keeps track of
+// the mirror in use, so that once a mirror has failed, the following pip
+// processes of the install use the next one.
+type mirrorList struct {
+	urls    []string
+	current int
+}
+
+// URL returns the mirror in use, or an empty string when no mirrors are
+// configured. It may contain credentials, so only Redacted is to be logged
+// or recorded.
+func (m *mirrorList) URL() string {
+	if len(m.urls) == 0 {
+		return ""
+	}
+	return m.urls[m.current]
+}
+
+// Redacted returns the mirror in use with its credentials removed.
+func (m *mirrorList) Redacted() string {
+	return redactCredentials(m.URL())
+}
+
+// Env returns the environment of a pip process that uses the mirror in use.
+func (m *mirrorList) Env(env []string) []string {
+	if len(m.urls) == 0 {
+		return env
+	}
+	return append(append([]string{}, env...), fmt.Sprintf("PIP_INDEX_URL=%s", m.URL()))
+}
+
+// next moves to the next mirror and reports whether there is one.
+func (m *mirrorList) next() bool {
+	if m.current+1 >= len(m.urls) {
+		return false
+	}
+
+	m.current++
+	return true
+}
+
+// failover runs the attempt, which uses the mirror in use, and again once
+// the next mirror is in use as long as it fails to reach the index. The
+// attempt returns the output pip reported its failure in.
+func (p PipInstallProcess) failover(ctx context.Context, mirrors *mirrorList, attempt func() (string, error)) error {
+	for {
+		output, err := attempt()
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		reason, network := networkFailure(output)
+		if !network {
+			return err
+		}
+
+		failed := mirrors.Redacted()
+		if !mirrors.next() {
+			return err
+		}
+
+		p.logger.Subprocess("Index mirror '%s' failed: %s", failed, reason)
+		p.logger.Subprocess("Falling back to index mirror '%s'", mirrors.Redacted())
+	}
+}
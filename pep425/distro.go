@@ -0,0 +1,31 @@
+package pep425
+
+// distroGlibc holds the glibc versions shipped by the Linux distributions
+// that buildpack targets are commonly based on, keyed by the distribution
+// name and version as given in CNB_TARGET_DISTRO_NAME and
+// CNB_TARGET_DISTRO_VERSION.
+var distroGlibc = map[string]map[string]string{
+	"ubuntu": {
+		"18.04": "2.27",
+		"20.04": "2.31",
+		"22.04": "2.35",
+		"24.04": "2.39",
+	},
+	"debian": {
+		"10": "2.28",
+		"11": "2.31",
+		"12": "2.36",
+	},
+	"rhel": {
+		"8": "2.28",
+		"9": "2.34",
+	},
+}
+
+// GlibcVersion returns the glibc version shipped by the given version of a
+// Linux distribution, such as "2.35" for Ubuntu 22.04, and reports whether
+// it is known.
+func GlibcVersion(distro, version string) (string, bool) {
+	glibc, ok := distroGlibc[distro][version]
+	return glibc, ok
+}
@@ -0,0 +1,15 @@
+package pep425_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitPEP425(t *testing.T) {
+	suite := spec.New("pep425", spec.Report(report.Terminal{}))
+	suite("Tag", testTag)
+	suite("Target", testTarget)
+	suite.Run(t)
+}
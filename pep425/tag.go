@@ -0,0 +1,90 @@
+// Package pep425 implements the compatibility tags of PEP 425 and the
+// platform tags of PEP 600 (manylinux) and PEP 656 (musllinux), following the
+// behaviour of the packaging library used by pip, so that the wheels a target
+// interpreter accepts can be determined without running it.
+package pep425
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tag is a compatibility tag, such as "cp312-cp312-manylinux_2_17_x86_64".
+type Tag struct {
+	Interpreter string
+	ABI         string
+	Platform    string
+}
+
+// String returns the tag in its "interpreter-abi-platform" form.
+func (t Tag) String() string {
+	return fmt.Sprintf("%s-%s-%s", t.Interpreter, t.ABI, t.Platform)
+}
+
+// ParseTag parses a tag, which may be a compressed tag set such as
+// "py2.py3-none-any", into the tags it stands for. Tags are case-insensitive
+// and returned in lowercase.
+func ParseTag(text string) ([]Tag, error) {
+	parts := strings.Split(strings.ToLower(text), "-")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid tag '%s': expected 'interpreter-abi-platform'", text)
+	}
+
+	var tags []Tag
+	for _, interpreter := range strings.Split(parts[0], ".") {
+		for _, abi := range strings.Split(parts[1], ".") {
+			for _, platform := range strings.Split(parts[2], ".") {
+				if interpreter == "" || abi == "" || platform == "" {
+					return nil, fmt.Errorf("invalid tag '%s': empty tag component", text)
+				}
+				tags = append(tags, Tag{Interpreter: interpreter, ABI: abi, Platform: platform})
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+// Wheel is a parsed wheel file name, such as
+// "MarkupSafe-3.0.2-cp312-cp312-manylinux_2_17_x86_64.manylinux2014_x86_64.whl".
+type Wheel struct {
+	// Name and Version are the project name and version, as given in the file
+	// name.
+	Name    string
+	Version string
+
+	// Build is the optional build tag.
+	Build string
+
+	// Tags are the tags the wheel is compatible with, expanded from its
+	// compressed tag set.
+	Tags []Tag
+}
+
+// ParseWheel parses the file name of a wheel.
+func ParseWheel(filename string) (Wheel, error) {
+	if !strings.HasSuffix(filename, ".whl") {
+		return Wheel{}, fmt.Errorf("invalid wheel filename '%s': missing '.whl' extension", filename)
+	}
+
+	parts := strings.Split(strings.TrimSuffix(filename, ".whl"), "-")
+	if len(parts) != 5 && len(parts) != 6 {
+		return Wheel{}, fmt.Errorf("invalid wheel filename '%s': wrong number of parts", filename)
+	}
+
+	wheel := Wheel{Name: parts[0], Version: parts[1]}
+	if len(parts) == 6 {
+		wheel.Build = parts[2]
+		if wheel.Build == "" || wheel.Build[0] < '0' || wheel.Build[0] > '9' {
+			return Wheel{}, fmt.Errorf("invalid wheel filename '%s': build tag must start with a digit", filename)
+		}
+	}
+
+	tags, err := ParseTag(strings.Join(parts[len(parts)-3:], "-"))
+	if err != nil {
+		return Wheel{}, fmt.Errorf("invalid wheel filename '%s': %w", filename, err)
+	}
+	wheel.Tags = tags
+
+	return wheel, nil
+}
@@ -0,0 +1,77 @@
+package pep425_test
+
+import (
+	"testing"
+
+	"github.com/paketo-buildpacks/pip-install/pep425"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testTag(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("ParseTag", func() {
+		it("parses a single tag", func() {
+			tags, err := pep425.ParseTag("cp312-cp312-manylinux_2_17_x86_64")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(Equal([]pep425.Tag{{Interpreter: "cp312", ABI: "cp312", Platform: "manylinux_2_17_x86_64"}}))
+			Expect(tags[0].String()).To(Equal("cp312-cp312-manylinux_2_17_x86_64"))
+		})
+
+		it("expands compressed tag sets", func() {
+			tags, err := pep425.ParseTag("py2.py3-none-ANY")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(Equal([]pep425.Tag{
+				{Interpreter: "py2", ABI: "none", Platform: "any"},
+				{Interpreter: "py3", ABI: "none", Platform: "any"},
+			}))
+		})
+
+		context("failure cases", func() {
+			it("returns an error for malformed tags", func() {
+				_, err := pep425.ParseTag("py3-none")
+				Expect(err).To(MatchError("invalid tag 'py3-none': expected 'interpreter-abi-platform'"))
+
+				_, err = pep425.ParseTag("py3.-none-any")
+				Expect(err).To(MatchError("invalid tag 'py3.-none-any': empty tag component"))
+			})
+		})
+	})
+
+	context("ParseWheel", func() {
+		it("parses a wheel file name", func() {
+			wheel, err := pep425.ParseWheel("MarkupSafe-3.0.2-cp312-cp312-manylinux_2_17_x86_64.manylinux2014_x86_64.whl")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wheel).To(Equal(pep425.Wheel{
+				Name:    "MarkupSafe",
+				Version: "3.0.2",
+				Tags: []pep425.Tag{
+					{Interpreter: "cp312", ABI: "cp312", Platform: "manylinux_2_17_x86_64"},
+					{Interpreter: "cp312", ABI: "cp312", Platform: "manylinux2014_x86_64"},
+				},
+			}))
+		})
+
+		it("parses the build tag", func() {
+			wheel, err := pep425.ParseWheel("flask-3.0.0-1local-py3-none-any.whl")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wheel.Build).To(Equal("1local"))
+			Expect(wheel.Tags).To(Equal([]pep425.Tag{{Interpreter: "py3", ABI: "none", Platform: "any"}}))
+		})
+
+		context("failure cases", func() {
+			it("returns an error for invalid file names", func() {
+				_, err := pep425.ParseWheel("flask-3.0.0.tar.gz")
+				Expect(err).To(MatchError("invalid wheel filename 'flask-3.0.0.tar.gz': missing '.whl' extension"))
+
+				_, err = pep425.ParseWheel("flask-3.0.0-py3-none.whl")
+				Expect(err).To(MatchError("invalid wheel filename 'flask-3.0.0-py3-none.whl': wrong number of parts"))
+
+				_, err = pep425.ParseWheel("flask-3.0.0-local-py3-none-any.whl")
+				Expect(err).To(MatchError("invalid wheel filename 'flask-3.0.0-local-py3-none-any.whl': build tag must start with a digit"))
+			})
+		})
+	})
+}
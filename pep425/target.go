@@ -0,0 +1,210 @@
+package pep425
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Machines maps the architectures of buildpack targets to the machine names
+// used in Linux platform tags, which are also the values of the
+// platform_machine marker on Linux.
+var Machines = map[string]string{
+	"amd64":   "x86_64",
+	"x86_64":  "x86_64",
+	"arm64":   "aarch64",
+	"aarch64": "aarch64",
+	"arm":     "armv7l",
+	"386":     "i686",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+}
+
+// legacyManylinux maps the glibc versions of the manylinux tags that predate
+// PEP 600 to their names.
+var legacyManylinux = map[int]string{
+	17: "manylinux2014",
+	12: "manylinux2010",
+	5:  "manylinux1",
+}
+
+// Target describes a CPython interpreter on Linux for which wheels are
+// selected.
+type Target struct {
+	// PythonVersion is the version of CPython, such as "3.12" or "3.12.4".
+	PythonVersion string
+
+	// Arch is the architecture, either as a buildpack target ("amd64",
+	// "arm64") or as a machine name ("x86_64", "aarch64").
+	Arch string
+
+	// Glibc is the version of glibc of the target, such as "2.35", for which
+	// manylinux wheels are accepted.
+	Glibc string
+
+	// Musl is the version of musl of the target, such as "1.2", for which
+	// musllinux wheels are accepted. When neither Glibc nor Musl is set,
+	// only wheels for the generic "linux" platform are accepted.
+	Musl string
+}
+
+// Tags returns the tags the target accepts, from the most to the least
+// preferred, in the same order as packaging.tags.sys_tags does for CPython.
+func (t Target) Tags() ([]Tag, error) {
+	major, minor, err := versionPair(t.PythonVersion, "Python version")
+	if err != nil {
+		return nil, err
+	}
+
+	if major != 3 {
+		return nil, fmt.Errorf("unsupported Python version '%s': only Python 3 is supported", t.PythonVersion)
+	}
+
+	platforms, err := t.platforms()
+	if err != nil {
+		return nil, err
+	}
+
+	interpreter := fmt.Sprintf("cp%d%d", major, minor)
+	abi := interpreter
+	if minor < 8 {
+		abi += "m"
+	}
+
+	var tags []Tag
+	for _, platform := range platforms {
+		tags = append(tags, Tag{interpreter, abi, platform})
+	}
+
+	abi3 := minor >= 2
+	if abi3 {
+		for _, platform := range platforms {
+			tags = append(tags, Tag{interpreter, "abi3", platform})
+		}
+	}
+
+	for _, platform := range platforms {
+		tags = append(tags, Tag{interpreter, "none", platform})
+	}
+
+	if abi3 {
+		for older := minor - 1; older > 1; older-- {
+			for _, platform := range platforms {
+				tags = append(tags, Tag{fmt.Sprintf("cp%d%d", major, older), "abi3", platform})
+			}
+		}
+	}
+
+	pythons := []string{fmt.Sprintf("py%d%d", major, minor), fmt.Sprintf("py%d", major)}
+	for older := minor - 1; older >= 0; older-- {
+		pythons = append(pythons, fmt.Sprintf("py%d%d", major, older))
+	}
+
+	for _, python := range pythons {
+		for _, platform := range platforms {
+			tags = append(tags, Tag{python, "none", platform})
+		}
+	}
+
+	tags = append(tags, Tag{interpreter, "none", "any"})
+	for _, python := range pythons {
+		tags = append(tags, Tag{python, "none", "any"})
+	}
+
+	return tags, nil
+}
+
+// platforms returns the platform tags the target accepts, from the most to
+// the least preferred.
+func (t Target) platforms() ([]string, error) {
+	machine := Machines[t.Arch]
+	if machine != "x86_64" && machine != "aarch64" {
+		return nil, fmt.Errorf("unsupported architecture '%s': must be one of 'amd64' or 'arm64'", t.Arch)
+	}
+
+	var platforms []string
+
+	if t.Glibc != "" {
+		major, minor, err := versionPair(t.Glibc, "glibc version")
+		if err != nil {
+			return nil, err
+		}
+
+		if major == 2 {
+			// manylinux wheels require glibc 2.5 on x86_64, and manylinux2014,
+			// i.e. glibc 2.17, is the oldest that supports aarch64.
+			oldest := 5
+			if machine == "aarch64" {
+				oldest = 17
+			}
+
+			for version := minor; version >= oldest; version-- {
+				platforms = append(platforms, fmt.Sprintf("manylinux_2_%d_%s", version, machine))
+				if legacy, ok := legacyManylinux[version]; ok {
+					platforms = append(platforms, fmt.Sprintf("%s_%s", legacy, machine))
+				}
+			}
+		}
+	}
+
+	if t.Musl != "" {
+		major, minor, err := versionPair(t.Musl, "musl version")
+		if err != nil {
+			return nil, err
+		}
+
+		if major == 1 {
+			for version := minor; version >= 0; version-- {
+				platforms = append(platforms, fmt.Sprintf("musllinux_1_%d_%s", version, machine))
+			}
+		}
+	}
+
+	return append(platforms, fmt.Sprintf("linux_%s", machine)), nil
+}
+
+// Supports returns the priority of the best of the tags that the target
+// accepts, where 0 is the most preferred, and reports whether the target
+// accepts any of them.
+func (t Target) Supports(tags []Tag) (int, bool, error) {
+	supported, err := t.Tags()
+	if err != nil {
+		return 0, false, err
+	}
+
+	priorities := map[Tag]int{}
+	for i, tag := range supported {
+		if _, ok := priorities[tag]; !ok {
+			priorities[tag] = i
+		}
+	}
+
+	best, found := 0, false
+	for _, tag := range tags {
+		if priority, ok := priorities[tag]; ok && (!found || priority < best) {
+			best, found = priority, true
+		}
+	}
+
+	return best, found, nil
+}
+
+// versionPair parses the major and minor components of a dotted version.
+func versionPair(version, kind string) (int, int, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("invalid %s '%s': expected 'major.minor'", kind, version)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s '%s': %w", kind, version, err)
+	}
+
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s '%s': %w", kind, version, err)
+	}
+
+	return major, minor, nil
+}
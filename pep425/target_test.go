@@ -0,0 +1,138 @@
+package pep425_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/paketo-buildpacks/pip-install/pep425"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testTarget(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	tagStrings := func(tags []pep425.Tag) []string {
+		var result []string
+		for _, tag := range tags {
+			result = append(result, tag.String())
+		}
+		return result
+	}
+
+	context("Tags", func() {
+		// The expected tags were generated with packaging.tags, for the
+		// same interpreter, architecture and libc.
+		var expected map[string][]string
+
+		it.Before(func() {
+			content, err := os.ReadFile("testdata/tags.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(content, &expected)).To(Succeed())
+		})
+
+		it("matches packaging for CPython 3.12 on amd64 with glibc 2.35", func() {
+			tags, err := pep425.Target{PythonVersion: "3.12.4", Arch: "amd64", Glibc: "2.35"}.Tags()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tagStrings(tags)).To(Equal(expected["cp312_x86_64_2.35"]))
+		})
+
+		it("matches packaging for CPython 3.12 on arm64 with glibc 2.35", func() {
+			tags, err := pep425.Target{PythonVersion: "3.12", Arch: "arm64", Glibc: "2.35"}.Tags()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tagStrings(tags)).To(Equal(expected["cp312_aarch64_2.35"]))
+		})
+
+		it("matches packaging for CPython 3.7, whose ABI tag has the pymalloc flag", func() {
+			tags, err := pep425.Target{PythonVersion: "3.7", Arch: "x86_64", Glibc: "2.17"}.Tags()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tagStrings(tags)).To(Equal(expected["cp37_x86_64_2.17"]))
+		})
+
+		it("matches packaging for CPython 3.11 on arm64 with musl 1.2", func() {
+			tags, err := pep425.Target{PythonVersion: "3.11", Arch: "aarch64", Musl: "1.2"}.Tags()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tagStrings(tags)).To(Equal(expected["cp311_aarch64_musl1.2"]))
+		})
+
+		it("only accepts the generic linux platform when the libc is unknown", func() {
+			tags, err := pep425.Target{PythonVersion: "3.12", Arch: "amd64"}.Tags()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tagStrings(tags)[:4]).To(Equal([]string{
+				"cp312-cp312-linux_x86_64",
+				"cp312-abi3-linux_x86_64",
+				"cp312-none-linux_x86_64",
+				"cp311-abi3-linux_x86_64",
+			}))
+		})
+
+		context("failure cases", func() {
+			it("returns an error for unsupported targets", func() {
+				_, err := pep425.Target{PythonVersion: "3", Arch: "amd64"}.Tags()
+				Expect(err).To(MatchError("invalid Python version '3': expected 'major.minor'"))
+
+				_, err = pep425.Target{PythonVersion: "2.7", Arch: "amd64"}.Tags()
+				Expect(err).To(MatchError("unsupported Python version '2.7': only Python 3 is supported"))
+
+				_, err = pep425.Target{PythonVersion: "3.12", Arch: "s390x"}.Tags()
+				Expect(err).To(MatchError("unsupported architecture 's390x': must be one of 'amd64' or 'arm64'"))
+
+				_, err = pep425.Target{PythonVersion: "3.12", Arch: "amd64", Glibc: "2.x"}.Tags()
+				Expect(err).To(MatchError(ContainSubstring("invalid glibc version '2.x'")))
+			})
+		})
+	})
+
+	context("Supports", func() {
+		var target pep425.Target
+
+		it.Before(func() {
+			target = pep425.Target{PythonVersion: "3.12", Arch: "amd64", Glibc: "2.35"}
+		})
+
+		it("returns the priority of the best supported tag", func() {
+			wheel, err := pep425.ParseWheel("MarkupSafe-3.0.2-cp312-cp312-manylinux_2_17_x86_64.manylinux2014_x86_64.whl")
+			Expect(err).NotTo(HaveOccurred())
+
+			priority, ok, err := target.Supports(wheel.Tags)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(priority).To(Equal(18))
+
+			universal, ok, err := target.Supports([]pep425.Tag{{Interpreter: "py3", ABI: "none", Platform: "any"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(universal).To(BeNumerically(">", priority))
+		})
+
+		it("reports tags the target does not accept", func() {
+			for _, filename := range []string{
+				"numpy-2.0.0-cp312-cp312-manylinux_2_39_x86_64.whl",
+				"numpy-2.0.0-cp312-cp312-manylinux_2_17_aarch64.whl",
+				"numpy-2.0.0-cp311-cp311-manylinux_2_17_x86_64.whl",
+				"numpy-2.0.0-cp312-cp312-musllinux_1_2_x86_64.whl",
+				"numpy-2.0.0-cp312-cp312-win_amd64.whl",
+			} {
+				wheel, err := pep425.ParseWheel(filename)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, ok, err := target.Supports(wheel.Tags)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ok).To(BeFalse(), filename)
+			}
+		})
+	})
+
+	context("GlibcVersion", func() {
+		it("returns the glibc version of known distributions", func() {
+			glibc, ok := pep425.GlibcVersion("ubuntu", "22.04")
+			Expect(ok).To(BeTrue())
+			Expect(glibc).To(Equal("2.35"))
+
+			_, ok = pep425.GlibcVersion("ubuntu", "99.04")
+			Expect(ok).To(BeFalse())
+		})
+	})
+}
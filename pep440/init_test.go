@@ -0,0 +1,15 @@
+package pep440_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitPEP440(t *testing.T) {
+	suite := spec.New("pep440", spec.Report(report.Terminal{}))
+	suite("Specifier", testSpecifier)
+	suite("Version", testVersion)
+	suite.Run(t)
+}
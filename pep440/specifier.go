@@ -0,0 +1,310 @@
+package pep440
+
+import (
+	"fmt"
+	"strings"
+)
+
+// operators are the specifier operators, longest first so that "===" is not
+// read as "==".
+var operators = []string{"===", "~=", "==", "!=", "<=", ">=", "<", ">"}
+
+// Specifier is a single version specifier clause, such as ">=1.0" or
+// "==2.*".
+type Specifier struct {
+	// Operator is one of "~=", "==", "!=", "<=", ">=", "<", ">" or "===".
+	Operator string
+
+	// Version is the version of the clause as given, including a trailing
+	// ".*" for wildcard clauses.
+	Version string
+
+	version  Version
+	wildcard bool
+}
+
+// ParseSpecifier parses a single version specifier clause.
+func ParseSpecifier(text string) (Specifier, error) {
+	text = strings.TrimSpace(text)
+
+	var specifier Specifier
+	for _, operator := range operators {
+		if strings.HasPrefix(text, operator) {
+			specifier.Operator = operator
+			specifier.Version = strings.TrimSpace(strings.TrimPrefix(text, operator))
+			break
+		}
+	}
+
+	if specifier.Operator == "" {
+		return Specifier{}, fmt.Errorf("invalid specifier '%s': missing operator", text)
+	}
+
+	if specifier.Version == "" || strings.ContainsAny(specifier.Version, " \t,;") {
+		return Specifier{}, fmt.Errorf("invalid specifier '%s': invalid version", text)
+	}
+
+	// Arbitrary equality compares strings, so any version is allowed.
+	if specifier.Operator == "===" {
+		if version, err := Parse(specifier.Version); err == nil {
+			specifier.version = version
+		}
+		return specifier, nil
+	}
+
+	version := specifier.Version
+	if strings.HasSuffix(version, ".*") {
+		if specifier.Operator != "==" && specifier.Operator != "!=" {
+			return Specifier{}, fmt.Errorf("invalid specifier '%s': wildcards are only allowed with '==' and '!='", text)
+		}
+		specifier.wildcard = true
+		version = strings.TrimSuffix(version, ".*")
+	}
+
+	parsed, err := Parse(version)
+	if err != nil {
+		return Specifier{}, fmt.Errorf("invalid specifier '%s': %w", text, err)
+	}
+
+	switch {
+	case specifier.wildcard && (parsed.pre != "" || parsed.post != nil || parsed.dev != nil || len(parsed.local) > 0):
+		return Specifier{}, fmt.Errorf("invalid specifier '%s': wildcards are only allowed after the release segment", text)
+
+	case len(parsed.local) > 0 && specifier.Operator != "==" && specifier.Operator != "!=":
+		return Specifier{}, fmt.Errorf("invalid specifier '%s': local versions are only allowed with '==' and '!='", text)
+
+	case specifier.Operator == "~=" && len(parsed.release) < 2:
+		return Specifier{}, fmt.Errorf("invalid specifier '%s': '~=' requires at least two release segments", text)
+	}
+
+	specifier.version = parsed
+
+	return specifier, nil
+}
+
+// String returns the specifier clause, such as ">=1.0".
+func (s Specifier) String() string {
+	return s.Operator + s.Version
+}
+
+// Prereleases reports whether the specifier admits pre-releases by default,
+// which is the case when it names a pre-release explicitly, e.g. ">=1.0rc1".
+func (s Specifier) Prereleases() bool {
+	switch s.Operator {
+	case "!=":
+		return false
+	case "===":
+		return s.version.release != nil && s.version.IsPrerelease()
+	}
+
+	return s.version.IsPrerelease()
+}
+
+// Contains reports whether the version satisfies the specifier.
+// Pre-releases only satisfy it when prereleases is true or the specifier
+// admits them, see Prereleases.
+func (s Specifier) Contains(version Version, prereleases bool) bool {
+	if version.IsPrerelease() && !prereleases && !s.Prereleases() {
+		return false
+	}
+
+	return s.matches(version)
+}
+
+func (s Specifier) matches(prospective Version) bool {
+	spec := s.version
+
+	switch s.Operator {
+	case "===":
+		return strings.EqualFold(prospective.String(), s.Version)
+
+	case "==":
+		return s.equal(prospective)
+
+	case "!=":
+		return !s.equal(prospective)
+
+	case "~=":
+		prefix := Specifier{
+			Operator: "==",
+			version:  Version{epoch: spec.epoch, release: spec.release[:len(spec.release)-1]},
+			wildcard: true,
+		}
+		return prospective.Public().Compare(spec) >= 0 && prefix.equal(prospective)
+
+	case "<=":
+		return prospective.Public().Compare(spec) <= 0
+
+	case ">=":
+		return prospective.Public().Compare(spec) >= 0
+
+	case "<":
+		if prospective.Compare(spec) >= 0 {
+			return false
+		}
+		// "<1.0" excludes the pre-releases of 1.0 unless it names a
+		// pre-release itself.
+		if !spec.IsPrerelease() && prospective.IsPrerelease() && prospective.BaseVersion().Equal(spec.BaseVersion()) {
+			return false
+		}
+		return true
+
+	case ">":
+		if prospective.Compare(spec) <= 0 {
+			return false
+		}
+		// ">1.0" excludes the post-releases and local versions of 1.0 unless
+		// it names a post-release itself.
+		if !spec.IsPostrelease() && prospective.IsPostrelease() && prospective.BaseVersion().Equal(spec.BaseVersion()) {
+			return false
+		}
+		if len(prospective.local) > 0 && prospective.BaseVersion().Equal(spec.BaseVersion()) {
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
+func (s Specifier) equal(prospective Version) bool {
+	spec := s.version
+
+	if s.wildcard {
+		if prospective.epoch != spec.epoch {
+			return false
+		}
+		for i := range spec.release {
+			if at(prospective.release, i) != spec.release[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// A version with a local label matches a specifier without one, e.g.
+	// "1.0+ubuntu.1" matches "==1.0".
+	if len(spec.local) == 0 {
+		prospective = prospective.Public()
+	}
+
+	return prospective.Equal(spec)
+}
+
+// SpecifierSet is a comma-separated list of specifier clauses, all of which
+// must be satisfied, such as ">=1.0,<2.0".
+type SpecifierSet []Specifier
+
+// ParseSpecifierSet parses a comma-separated list of specifier clauses. An
+// empty string is an empty set, which any final release satisfies.
+func ParseSpecifierSet(text string) (SpecifierSet, error) {
+	var set SpecifierSet
+	for _, clause := range strings.Split(text, ",") {
+		if strings.TrimSpace(clause) == "" {
+			continue
+		}
+
+		specifier, err := ParseSpecifier(clause)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, specifier)
+	}
+
+	return set, nil
+}
+
+// String returns the clauses of the set joined by commas.
+func (s SpecifierSet) String() string {
+	clauses := make([]string, len(s))
+	for i, specifier := range s {
+		clauses[i] = specifier.String()
+	}
+	return strings.Join(clauses, ",")
+}
+
+// Prereleases reports whether any clause of the set admits pre-releases.
+func (s SpecifierSet) Prereleases() bool {
+	for _, specifier := range s {
+		if specifier.Prereleases() {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the version satisfies every clause of the set.
+// Pre-releases only satisfy it when prereleases is true or any clause admits
+// them.
+func (s SpecifierSet) Contains(version Version, prereleases bool) bool {
+	prereleases = prereleases || s.Prereleases()
+	if version.IsPrerelease() && !prereleases {
+		return false
+	}
+
+	for _, specifier := range s {
+		if !specifier.Contains(version, prereleases) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Satisfiable reports whether any version, pre-releases included, satisfies
+// every clause of the set. Rather than enumerating all versions, it tries
+// the versions named by the clauses and their neighbours, which finds a
+// version for any set that is not contradictory in practice, while sets such
+// as "==1.0,>=2.0" or "~=1.4,<1.4" are reported as unsatisfiable.
+func (s SpecifierSet) Satisfiable() bool {
+	candidates := []Version{{release: []int{0}}}
+	for _, specifier := range s {
+		// Arbitrary equality with a string that is not a version cannot be
+		// checked against versions.
+		if specifier.version.release == nil {
+			return true
+		}
+		candidates = append(candidates, neighbours(specifier.version)...)
+	}
+
+	for _, candidate := range candidates {
+		if s.Contains(candidate, true) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// neighbours returns the version along with versions just above and below
+// it in every release segment, and its post-, development and sub-releases.
+func neighbours(version Version) []Version {
+	release := func(numbers ...int) Version {
+		return Version{epoch: version.epoch, release: numbers}
+	}
+
+	post, dev := 1, 0
+	result := []Version{
+		version,
+		version.Public(),
+		release(append(append([]int{}, version.release...), 1)...),
+		release(append(append([]int{}, version.release...), 0, 1)...),
+		{epoch: version.epoch, release: version.release, post: &post},
+		{epoch: version.epoch, release: version.release, dev: &dev},
+		{epoch: version.epoch, release: version.release, pre: "a", preNumber: 0},
+	}
+
+	for i := range version.release {
+		up := append([]int{}, version.release[:i+1]...)
+		up[i]++
+		result = append(result, release(up...))
+
+		if version.release[i] > 0 {
+			down := append([]int{}, version.release[:i+1]...)
+			down[i]--
+			result = append(result, release(append(down, 999999)...))
+		}
+	}
+
+	return result
+}
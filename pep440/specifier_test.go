@@ -0,0 +1,296 @@
+package pep440_test
+
+import (
+	"testing"
+
+	"github.com/paketo-buildpacks/pip-install/pep440"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testSpecifier(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("ParseSpecifier", func() {
+		it("parses valid specifiers", func() {
+			for text, expected := range map[string]pep440.Specifier{
+				"==1.0":        {Operator: "==", Version: "1.0"},
+				"== 1.0":       {Operator: "==", Version: "1.0"},
+				" >=1.0 ":      {Operator: ">=", Version: "1.0"},
+				"~=2.2":        {Operator: "~=", Version: "2.2"},
+				"~=1.4.5a4":    {Operator: "~=", Version: "1.4.5a4"},
+				"~=2.2.post3":  {Operator: "~=", Version: "2.2.post3"},
+				"==2.*":        {Operator: "==", Version: "2.*"},
+				"!=1!2.1.*":    {Operator: "!=", Version: "1!2.1.*"},
+				"==1.0+local":  {Operator: "==", Version: "1.0+local"},
+				"!=1.0+local":  {Operator: "!=", Version: "1.0+local"},
+				"<2.0.dev1":    {Operator: "<", Version: "2.0.dev1"},
+				">1.0.post1":   {Operator: ">", Version: "1.0.post1"},
+				"<=v1.0":       {Operator: "<=", Version: "v1.0"},
+				"===foobar":    {Operator: "===", Version: "foobar"},
+				"===1.0+local": {Operator: "===", Version: "1.0+local"},
+			} {
+				specifier, err := pep440.ParseSpecifier(text)
+				Expect(err).NotTo(HaveOccurred(), text)
+				Expect(specifier.Operator).To(Equal(expected.Operator), text)
+				Expect(specifier.Version).To(Equal(expected.Version), text)
+				Expect(specifier.String()).To(Equal(expected.Operator+expected.Version), text)
+			}
+		})
+
+		it("rejects invalid specifiers", func() {
+			for _, text := range []string{
+				"",
+				"1.0",
+				"=1.0",
+				"=>1.0",
+				"==",
+				"==foo",
+				"==1.0 1",
+				"~=1",
+				"~=1.0+local",
+				"~=1.*",
+				">=1.0+local",
+				"<1.0+local",
+				">1.*",
+				"<=1.*",
+				"==1.0a1.*",
+				"==1.0.post1.*",
+				"==1.0.dev1.*",
+				"==1.0+local.*",
+				"==1.*.0",
+				"===",
+				"===foo bar",
+			} {
+				_, err := pep440.ParseSpecifier(text)
+				Expect(err).To(MatchError(ContainSubstring("invalid specifier")), text)
+			}
+		})
+	})
+
+	context("Contains", func() {
+		it("matches versions as pip does", func() {
+			type example struct {
+				version   string
+				specifier string
+				expected  bool
+			}
+
+			for _, e := range []example{
+				// Equality
+				{"2.0", "==2", true},
+				{"2.0", "==2.0", true},
+				{"2.0", "==2.0.0", true},
+				{"2.0", "==2.0.1", false},
+				{"2.0+deadbeef", "==2", true},
+				{"2.0+deadbeef", "==2.0+deadbeef", true},
+				{"2.0+deadbeef", "==2.0+other", false},
+				{"2.0", "==2.0+deadbeef", false},
+				{"2.0.post1", "==2.0", false},
+				{"1!2.0", "==2.0", false},
+
+				// Wildcard equality
+				{"2.0", "==2.*", true},
+				{"2", "==2.0.*", true},
+				{"2.1", "==2.*", true},
+				{"2.1.5", "==2.1.*", true},
+				{"2.10", "==2.1.*", false},
+				{"3.0", "==2.*", false},
+				{"2.0.post1", "==2.0.*", true},
+				{"2.0+local", "==2.0.*", true},
+				{"2.0", "==1!2.*", false},
+				{"1!2.0", "==1!2.*", true},
+
+				// Inequality
+				{"2.1", "!=2", true},
+				{"2.0", "!=2", false},
+				{"2.0", "!=2.0.0", false},
+				{"2.0+deadbeef", "!=2.0", false},
+				{"2.0", "!=2.0+deadbeef", true},
+				{"2.0.post1", "!=2.0", true},
+				{"2.1", "!=2.0.*", true},
+				{"2.0.1", "!=2.0.*", false},
+
+				// Compatible release
+				{"2.0", "~=2.0", true},
+				{"2.5", "~=2.0", true},
+				{"3.0", "~=2.0", false},
+				{"1.9", "~=2.0", false},
+				{"2.2.5", "~=2.2.0", true},
+				{"2.3.0", "~=2.2.0", false},
+				{"2.2.0+local", "~=2.2.0", true},
+				{"2.2.post3", "~=2.2.post3", true},
+				{"2.3", "~=2.2.post3", true},
+				{"2.2", "~=2.2.post3", false},
+				{"2.2.post2", "~=2.2.post3", false},
+				{"1.4.5a5", "~=1.4.5a4", true},
+				{"1.4.5", "~=1.4.5a4", true},
+				{"1.5", "~=1.4.5a4", false},
+				{"1!2.5", "~=2.0", false},
+
+				// Ordered comparisons
+				{"2.0", ">=2", true},
+				{"2.0+local", ">=2", true},
+				{"2.1", ">=2.0", true},
+				{"1.9", ">=2.0", false},
+				{"2.0", "<=2", true},
+				{"2.0+local", "<=2", true},
+				{"2.1", "<=2.0", false},
+				{"2.0.post1", ">=2.0", true},
+				{"2.0.post1", "<=2.0", false},
+
+				// Exclusive ordered comparisons
+				{"1.9", "<2", true},
+				{"2.0", "<2", false},
+				{"2.0a1", "<2", false},
+				{"2.0.dev1", "<2", false},
+				{"1.9a1", "<2", false},
+				{"2.0a1", "<2.0b1", true},
+				{"2.0.dev1", "<2.0rc1", true},
+				{"2.1", ">2", true},
+				{"2.0", ">2", false},
+				{"2.0.post1", ">2", false},
+				{"2.0+local", ">2", false},
+				{"2.0.1", ">2", true},
+				{"2.0.post2", ">2.0.post1", true},
+				{"2.0.post1+local", ">2.0.post1", false},
+				{"3.0a1", ">2", false},
+
+				// Epochs
+				{"1!1.0", ">2.0", true},
+				{"1!1.0", "<2.0", false},
+				{"1!1.0", ">=1!1.0", true},
+
+				// Arbitrary equality
+				{"1.0", "===1.0", true},
+				{"1.0", "===1.0.0", false},
+				{"1.0+LOCAL", "===1.0+local", true},
+			} {
+				version := pep440.MustParse(e.version)
+
+				specifier, err := pep440.ParseSpecifier(e.specifier)
+				Expect(err).NotTo(HaveOccurred(), e.specifier)
+				Expect(specifier.Contains(version, false)).To(Equal(e.expected), "%s in %s", e.version, e.specifier)
+			}
+		})
+
+		context("pre-releases", func() {
+			it("excludes them unless requested or named by the specifier", func() {
+				type example struct {
+					version     string
+					specifier   string
+					prereleases bool
+					expected    bool
+				}
+
+				for _, e := range []example{
+					{"2.0a1", ">=1.0", false, false},
+					{"2.0a1", ">=1.0", true, true},
+					{"2.0.dev1", ">=1.0", false, false},
+					{"2.0.dev1", ">=1.0", true, true},
+					{"2.0a1", ">=2.0a1", false, true},
+					{"2.0b1", ">=2.0a1", false, true},
+					{"2.0a1", "==2.0a1", false, true},
+					{"2.0a1", "==2.*", false, false},
+					{"2.0a1", "==2.*", true, true},
+					{"2.0a1", "!=2.0a1", false, false},
+					{"2.0a2", "!=2.0a1", false, false},
+					{"2.0a2", "!=2.0a1", true, true},
+					{"2.0.post1", ">=1.0", false, true},
+					{"1.0.dev1", ">1.0.dev0", false, true},
+					{"1.0a1", "===1.0a1", false, true},
+				} {
+					specifier, err := pep440.ParseSpecifier(e.specifier)
+					Expect(err).NotTo(HaveOccurred(), e.specifier)
+					Expect(specifier.Contains(pep440.MustParse(e.version), e.prereleases)).To(Equal(e.expected), "%s in %s (prereleases: %t)", e.version, e.specifier, e.prereleases)
+				}
+			})
+		})
+	})
+
+	context("SpecifierSet", func() {
+		it("parses comma-separated clauses", func() {
+			set, err := pep440.ParseSpecifierSet(" >=1.0, <2.0 ,!=1.5.*,")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set).To(HaveLen(3))
+			Expect(set.String()).To(Equal(">=1.0,<2.0,!=1.5.*"))
+		})
+
+		it("returns an empty set for an empty string", func() {
+			set, err := pep440.ParseSpecifierSet("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set).To(BeEmpty())
+			Expect(set.Contains(pep440.MustParse("1.0"), false)).To(BeTrue())
+			Expect(set.Contains(pep440.MustParse("1.0a1"), false)).To(BeFalse())
+			Expect(set.Contains(pep440.MustParse("1.0a1"), true)).To(BeTrue())
+		})
+
+		it("requires every clause to match", func() {
+			set, err := pep440.ParseSpecifierSet(">=1.0,<2.0,!=1.5.*")
+			Expect(err).NotTo(HaveOccurred())
+
+			for version, expected := range map[string]bool{
+				"0.9":    false,
+				"1.0":    true,
+				"1.4.9":  true,
+				"1.5":    false,
+				"1.5.1":  false,
+				"1.6":    true,
+				"2.0":    false,
+				"2.0rc1": false,
+				"1.6rc1": false,
+			} {
+				Expect(set.Contains(pep440.MustParse(version), false)).To(Equal(expected), version)
+			}
+		})
+
+		it("admits pre-releases when any clause names one", func() {
+			set, err := pep440.ParseSpecifierSet(">=1.0rc1,<2.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Prereleases()).To(BeTrue())
+			Expect(set.Contains(pep440.MustParse("1.5a1"), false)).To(BeTrue())
+			Expect(set.Contains(pep440.MustParse("2.0a1"), false)).To(BeFalse())
+		})
+
+		it("reports whether any version satisfies the set", func() {
+			for text, expected := range map[string]bool{
+				"":                       true,
+				">=1.0":                  true,
+				"==1.0,==1.0.0":          true,
+				"==1.0,>=1.0,<2":         true,
+				">=1.0,<2.0":             true,
+				">1.0,<1.1":              true,
+				">1.0,<1.0.1":            true,
+				">=1.0,!=1.0":            true,
+				"~=1.4,<1.5":             true,
+				"==1.*,>1.9":             true,
+				"<1.0,>=0.9a1":           true,
+				"<1.0,>=1.0a1":           false,
+				"==1.0+a,==1.0":          true,
+				"===foobar":              true,
+				"<=1.0,>=1.0":            true,
+				">=2.0,<3,!=2.5.*,~=2.4": true,
+				"==1.0,==2.0":            false,
+				"==1.0,>=2.0":            false,
+				">=2,<2":                 false,
+				">2,<=2":                 false,
+				"~=1.4,<1.4":             false,
+				"~=1.4,>=2":              false,
+				"==1.*,==2.*":            false,
+				"==1.0,!=1.0":            false,
+				"==1.0+a,==1.0+b":        false,
+				"==1!1.0,==1.0":          false,
+			} {
+				set, err := pep440.ParseSpecifierSet(text)
+				Expect(err).NotTo(HaveOccurred(), text)
+				Expect(set.Satisfiable()).To(Equal(expected), text)
+			}
+		})
+
+		it("returns an error for invalid clauses", func() {
+			_, err := pep440.ParseSpecifierSet(">=1.0,=<2.0")
+			Expect(err).To(MatchError(ContainSubstring("invalid specifier '=<2.0'")))
+		})
+	})
+}
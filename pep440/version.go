@@ -0,0 +1,354 @@
+// Package pep440 implements the version scheme and version specifiers of
+// PEP 440, following the behaviour of the packaging library used by pip.
+package pep440
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionPattern = regexp.MustCompile(`(?i)^v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?P<pre>[-_.]?(?P<pre_l>alpha|beta|preview|pre|rc|a|b|c)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?P<post>-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+	`(?P<dev>[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// Version is a parsed PEP 440 version.
+type Version struct {
+	epoch   int
+	release []int
+
+	// pre is "a", "b" or "rc", or empty for versions that are not
+	// pre-releases.
+	pre       string
+	preNumber int
+
+	post *int
+	dev  *int
+
+	// local holds the segments of the local version label, which are either
+	// numbers or lowercase alphanumeric strings.
+	local []localSegment
+}
+
+type localSegment struct {
+	number  int
+	text    string
+	numeric bool
+}
+
+// Parse parses a version, accepting any of the spellings that PEP 440
+// normalizes, such as "1.0-RC.1" or "v2.0.post".
+func Parse(text string) (Version, error) {
+	match := versionPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return Version{}, fmt.Errorf("invalid version '%s'", text)
+	}
+
+	group := func(name string) string {
+		return match[versionPattern.SubexpIndex(name)]
+	}
+
+	var (
+		version Version
+		err     error
+	)
+
+	if epoch := group("epoch"); epoch != "" {
+		version.epoch, err = parseNumber(epoch)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version '%s': %w", text, err)
+		}
+	}
+
+	for _, part := range strings.Split(group("release"), ".") {
+		number, err := parseNumber(part)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version '%s': %w", text, err)
+		}
+		version.release = append(version.release, number)
+	}
+
+	if label := strings.ToLower(group("pre_l")); label != "" {
+		switch label {
+		case "alpha":
+			label = "a"
+		case "beta":
+			label = "b"
+		case "c", "pre", "preview":
+			label = "rc"
+		}
+		version.pre = label
+
+		if number := group("pre_n"); number != "" {
+			version.preNumber, err = parseNumber(number)
+			if err != nil {
+				return Version{}, fmt.Errorf("invalid version '%s': %w", text, err)
+			}
+		}
+	}
+
+	if group("post") != "" {
+		number := group("post_n1") + group("post_n2")
+		post := 0
+		if number != "" {
+			post, err = parseNumber(number)
+			if err != nil {
+				return Version{}, fmt.Errorf("invalid version '%s': %w", text, err)
+			}
+		}
+		version.post = &post
+	}
+
+	if group("dev") != "" {
+		dev := 0
+		if number := group("dev_n"); number != "" {
+			dev, err = parseNumber(number)
+			if err != nil {
+				return Version{}, fmt.Errorf("invalid version '%s': %w", text, err)
+			}
+		}
+		version.dev = &dev
+	}
+
+	if local := group("local"); local != "" {
+		for _, part := range strings.FieldsFunc(strings.ToLower(local), func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		}) {
+			if number, err := strconv.Atoi(part); err == nil {
+				version.local = append(version.local, localSegment{number: number, numeric: true})
+			} else {
+				version.local = append(version.local, localSegment{text: part})
+			}
+		}
+	}
+
+	return version, nil
+}
+
+// MustParse is like Parse but panics if the version is invalid. It is
+// intended for versions known at compile time.
+func MustParse(text string) Version {
+	version, err := Parse(text)
+	if err != nil {
+		panic(err)
+	}
+	return version
+}
+
+// Normalize returns the normalized form of a version, such as "1.0rc1" for
+// "1.0-RC.1".
+func Normalize(text string) (string, error) {
+	version, err := Parse(text)
+	if err != nil {
+		return "", err
+	}
+	return version.String(), nil
+}
+
+func parseNumber(text string) (int, error) {
+	number, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("number '%s' is out of range", text)
+	}
+	return number, nil
+}
+
+// String returns the normalized form of the version.
+func (v Version) String() string {
+	var builder strings.Builder
+
+	if v.epoch != 0 {
+		fmt.Fprintf(&builder, "%d!", v.epoch)
+	}
+
+	builder.WriteString(v.releaseString())
+
+	if v.pre != "" {
+		fmt.Fprintf(&builder, "%s%d", v.pre, v.preNumber)
+	}
+	if v.post != nil {
+		fmt.Fprintf(&builder, ".post%d", *v.post)
+	}
+	if v.dev != nil {
+		fmt.Fprintf(&builder, ".dev%d", *v.dev)
+	}
+	if len(v.local) > 0 {
+		builder.WriteString("+")
+		builder.WriteString(v.Local())
+	}
+
+	return builder.String()
+}
+
+func (v Version) releaseString() string {
+	parts := make([]string, len(v.release))
+	for i, number := range v.release {
+		parts[i] = strconv.Itoa(number)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Epoch returns the epoch of the version, which is 0 unless given.
+func (v Version) Epoch() int {
+	return v.epoch
+}
+
+// Release returns the release segment of the version, such as [1 2 0] for
+// "1.2.0rc1".
+func (v Version) Release() []int {
+	return append([]int{}, v.release...)
+}
+
+// Local returns the normalized local version label, such as "ubuntu.1" for
+// "1.0+ubuntu-1", or an empty string.
+func (v Version) Local() string {
+	parts := make([]string, len(v.local))
+	for i, segment := range v.local {
+		if segment.numeric {
+			parts[i] = strconv.Itoa(segment.number)
+		} else {
+			parts[i] = segment.text
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// Public returns the version without its local version label.
+func (v Version) Public() Version {
+	v.local = nil
+	return v
+}
+
+// BaseVersion returns the epoch and release segment of the version only.
+func (v Version) BaseVersion() Version {
+	return Version{epoch: v.epoch, release: v.release}
+}
+
+// IsPrerelease reports whether the version is a pre-release or a
+// development release.
+func (v Version) IsPrerelease() bool {
+	return v.pre != "" || v.dev != nil
+}
+
+// IsPostrelease reports whether the version is a post-release.
+func (v Version) IsPostrelease() bool {
+	return v.post != nil
+}
+
+// IsDevrelease reports whether the version is a development release.
+func (v Version) IsDevrelease() bool {
+	return v.dev != nil
+}
+
+// Equal reports whether the versions are equal, e.g. "1.0" and "1.0.0".
+func (v Version) Equal(other Version) bool {
+	return v.Compare(other) == 0
+}
+
+// Compare returns -1, 0 or 1 depending on whether v sorts before, equal to
+// or after other.
+func (v Version) Compare(other Version) int {
+	if c := compareInts(v.epoch, other.epoch); c != 0 {
+		return c
+	}
+
+	// Trailing zeros of the release segment are not significant.
+	for i := 0; i < len(v.release) || i < len(other.release); i++ {
+		if c := compareInts(at(v.release, i), at(other.release, i)); c != 0 {
+			return c
+		}
+	}
+
+	if c := compareInts(v.preRank(), other.preRank()); c != 0 {
+		return c
+	}
+	if v.pre != "" && other.pre != "" {
+		if c := strings.Compare(v.pre, other.pre); c != 0 {
+			return c
+		}
+		if c := compareInts(v.preNumber, other.preNumber); c != 0 {
+			return c
+		}
+	}
+
+	if c := compareOptional(v.post, other.post, -1); c != 0 {
+		return c
+	}
+
+	if c := compareOptional(v.dev, other.dev, 1); c != 0 {
+		return c
+	}
+
+	return compareLocal(v.local, other.local)
+}
+
+// preRank orders the pre-release segment: a development release of a final
+// release sorts before its pre-releases, which sort before the final release.
+func (v Version) preRank() int {
+	switch {
+	case v.pre == "" && v.post == nil && v.dev != nil:
+		return -1
+	case v.pre == "":
+		return 1
+	}
+	return 0
+}
+
+// compareOptional compares optional numbers, where a missing number sorts
+// before all numbers when missing is -1 and after them when it is 1.
+func compareOptional(left, right *int, missing int) int {
+	switch {
+	case left == nil && right == nil:
+		return 0
+	case left == nil:
+		return missing
+	case right == nil:
+		return -missing
+	}
+	return compareInts(*left, *right)
+}
+
+// compareLocal compares local version labels, where numeric segments sort
+// after alphanumeric ones and a version without a label sorts first.
+func compareLocal(left, right []localSegment) int {
+	for i := 0; i < len(left) && i < len(right); i++ {
+		l, r := left[i], right[i]
+		switch {
+		case l.numeric && r.numeric:
+			if c := compareInts(l.number, r.number); c != 0 {
+				return c
+			}
+		case l.numeric:
+			return 1
+		case r.numeric:
+			return -1
+		default:
+			if c := strings.Compare(l.text, r.text); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(left), len(right))
+}
+
+func compareInts(left, right int) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+	return 0
+}
+
+func at(numbers []int, index int) int {
+	if index < len(numbers) {
+		return numbers[index]
+	}
+	return 0
+}
@@ -0,0 +1,214 @@
+package pep440_test
+
+import (
+	"testing"
+
+	"github.com/paketo-buildpacks/pip-install/pep440"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testVersion(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Parse", func() {
+		it("normalizes versions", func() {
+			for version, expected := range map[string]string{
+				// Release segment
+				"1":            "1",
+				"1.0":          "1.0",
+				"1.0.0":        "1.0.0",
+				"01.02.003":    "1.2.3",
+				"v1.0":         "1.0",
+				"V1.0":         "1.0",
+				" 1.0\n":       "1.0",
+				"2024.10.19.1": "2024.10.19.1",
+
+				// Epochs
+				"0!1.0": "1.0",
+				"1!1.0": "1!1.0",
+				"07!1":  "7!1",
+
+				// Pre-releases
+				"1.0a1":       "1.0a1",
+				"1.0a":        "1.0a0",
+				"1.0.a1":      "1.0a1",
+				"1.0-a1":      "1.0a1",
+				"1.0_a1":      "1.0a1",
+				"1.0a.1":      "1.0a1",
+				"1.0a-1":      "1.0a1",
+				"1.0alpha1":   "1.0a1",
+				"1.0ALPHA1":   "1.0a1",
+				"1.0b2":       "1.0b2",
+				"1.0beta2":    "1.0b2",
+				"1.0c1":       "1.0rc1",
+				"1.0pre1":     "1.0rc1",
+				"1.0preview1": "1.0rc1",
+				"1.0rc1":      "1.0rc1",
+				"1.0RC1":      "1.0rc1",
+				"1.0-RC.1":    "1.0rc1",
+				"1.0rc01":     "1.0rc1",
+
+				// Post-releases
+				"1.0.post1":  "1.0.post1",
+				"1.0post1":   "1.0.post1",
+				"1.0-post1":  "1.0.post1",
+				"1.0_post1":  "1.0.post1",
+				"1.0.post":   "1.0.post0",
+				"1.0.post.1": "1.0.post1",
+				"1.0-1":      "1.0.post1",
+				"1.0r1":      "1.0.post1",
+				"1.0rev1":    "1.0.post1",
+				"1.0.POST1":  "1.0.post1",
+
+				// Development releases
+				"1.0.dev1": "1.0.dev1",
+				"1.0dev1":  "1.0.dev1",
+				"1.0-dev1": "1.0.dev1",
+				"1.0.dev":  "1.0.dev0",
+				"1.0.DEV1": "1.0.dev1",
+
+				// Combinations
+				"1.0a1.post2.dev3":    "1.0a1.post2.dev3",
+				"1.0-alpha-1-post-2":  "1.0a1.post2",
+				"1!1.0rc1.post1.dev1": "1!1.0rc1.post1.dev1",
+				"1.0.post1.dev2":      "1.0.post1.dev2",
+
+				// Local versions
+				"1.0+abc":           "1.0+abc",
+				"1.0+ABC":           "1.0+abc",
+				"1.0+abc.5":         "1.0+abc.5",
+				"1.0+abc-5":         "1.0+abc.5",
+				"1.0+abc_5":         "1.0+abc.5",
+				"1.0+5.abc":         "1.0+5.abc",
+				"1.0+007":           "1.0+7",
+				"1.0+ubuntu-1":      "1.0+ubuntu.1",
+				"1.0rc1.dev2+local": "1.0rc1.dev2+local",
+			} {
+				normalized, err := pep440.Normalize(version)
+				Expect(err).NotTo(HaveOccurred(), version)
+				Expect(normalized).To(Equal(expected), version)
+			}
+		})
+
+		it("rejects invalid versions", func() {
+			for _, version := range []string{
+				"",
+				"version",
+				"1.0.",
+				".1.0",
+				"1..0",
+				"1.0a1a1",
+				"1.0-dev1-1",
+				"1.0+",
+				"1.0+abc..1",
+				"1.0+abc.",
+				"1.0 1",
+				"1.0.*",
+				"!1.0",
+				"1!",
+				"1.0gamma1",
+				"1.0-",
+				"1.0+a b",
+				"1.0.99999999999999999999",
+			} {
+				_, err := pep440.Parse(version)
+				Expect(err).To(MatchError(ContainSubstring("invalid version")), version)
+			}
+		})
+	})
+
+	context("Compare", func() {
+		it("orders versions", func() {
+			// Each version sorts strictly after the previous one.
+			versions := []string{
+				"1.0.dev456",
+				"1.0a1",
+				"1.0a2.dev456",
+				"1.0a12.dev456",
+				"1.0a12",
+				"1.0b1.dev456",
+				"1.0b2",
+				"1.0b2.post345.dev456",
+				"1.0b2.post345",
+				"1.0b2-346",
+				"1.0c1.dev456",
+				"1.0rc2",
+				"1.0c3",
+				"1.0",
+				"1.0.post456.dev34",
+				"1.0.post456",
+				"1.1.dev1",
+				"1.2+123abc",
+				"1.2+123abc456",
+				"1.2+abc",
+				"1.2+abc123",
+				"1.2+abc123def",
+				"1.2+1234.abc",
+				"1.2+123456",
+				"1.2.r32+123456",
+				"1.2.rev33+123456",
+				"1.10",
+				"2.0.0rc1",
+				"2.0.0",
+				"1!0.1",
+				"1!1.0",
+			}
+
+			for i := 1; i < len(versions); i++ {
+				previous, current := pep440.MustParse(versions[i-1]), pep440.MustParse(versions[i])
+				Expect(previous.Compare(current)).To(Equal(-1), "%s < %s", versions[i-1], versions[i])
+				Expect(current.Compare(previous)).To(Equal(1), "%s > %s", versions[i], versions[i-1])
+			}
+		})
+
+		it("treats equivalent spellings as equal", func() {
+			for left, right := range map[string]string{
+				"1.0":       "1.0.0",
+				"1":         "1.0.0.0",
+				"1.0rc1":    "1.0c1",
+				"1.0-1":     "1.0.post1",
+				"0!1.0":     "1.0",
+				"1.0+ABC.1": "1.0+abc-1",
+				"1.0+01":    "1.0+1",
+				"1.0alpha":  "1.0a0",
+				"1.0.dev":   "1.0dev0",
+			} {
+				Expect(pep440.MustParse(left).Equal(pep440.MustParse(right))).To(BeTrue(), "%s == %s", left, right)
+			}
+		})
+	})
+
+	context("segments", func() {
+		it("reports the kind of release", func() {
+			for version, expected := range map[string][3]bool{
+				"1.0":            {false, false, false},
+				"1.0a1":          {true, false, false},
+				"1.0rc1":         {true, false, false},
+				"1.0.dev1":       {true, false, true},
+				"1.0.post1":      {false, true, false},
+				"1.0.post1.dev1": {true, true, true},
+				"1.0+local":      {false, false, false},
+			} {
+				parsed := pep440.MustParse(version)
+				Expect([3]bool{parsed.IsPrerelease(), parsed.IsPostrelease(), parsed.IsDevrelease()}).To(Equal(expected), version)
+			}
+		})
+
+		it("returns the public and base versions", func() {
+			parsed := pep440.MustParse("1!2.3.4rc1.post5.dev6+ubuntu-1")
+			Expect(parsed.Epoch()).To(Equal(1))
+			Expect(parsed.Release()).To(Equal([]int{2, 3, 4}))
+			Expect(parsed.Local()).To(Equal("ubuntu.1"))
+			Expect(parsed.Public().String()).To(Equal("1!2.3.4rc1.post5.dev6"))
+			Expect(parsed.BaseVersion().String()).To(Equal("1!2.3.4"))
+		})
+	})
+
+	context("MustParse", func() {
+		it("panics on invalid versions", func() {
+			Expect(func() { pep440.MustParse("invalid") }).To(Panic())
+		})
+	})
+}
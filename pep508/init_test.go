@@ -0,0 +1,15 @@
+package pep508_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitPEP508(t *testing.T) {
+	suite := spec.New("pep508", spec.Report(report.Terminal{}))
+	suite("Marker", testMarker)
+	suite("Requirement", testRequirement)
+	suite.Run(t)
+}
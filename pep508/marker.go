@@ -0,0 +1,194 @@
+package pep508
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Environment holds the values of the environment marker variables of a
+// Python interpreter, as described in PEP 508. The JSON field names match the
+// marker variable names.
+type Environment struct {
+	ImplementationName           string `json:"implementation_name"`
+	ImplementationVersion        string `json:"implementation_version"`
+	OSName                       string `json:"os_name"`
+	PlatformMachine              string `json:"platform_machine"`
+	PlatformPythonImplementation string `json:"platform_python_implementation"`
+	PlatformRelease              string `json:"platform_release"`
+	PlatformSystem               string `json:"platform_system"`
+	PlatformVersion              string `json:"platform_version"`
+	PythonFullVersion            string `json:"python_full_version"`
+	PythonVersion                string `json:"python_version"`
+	SysPlatform                  string `json:"sys_platform"`
+}
+
+// legacyVariables maps the marker variable names of PEP 345, which pip still
+// accepts, onto the names of PEP 508.
+var legacyVariables = map[string]string{
+	"os.name":                        "os_name",
+	"sys.platform":                   "sys_platform",
+	"platform.machine":               "platform_machine",
+	"platform.version":               "platform_version",
+	"platform.python_implementation": "platform_python_implementation",
+	"python_implementation":          "platform_python_implementation",
+}
+
+func (e Environment) lookup(variable, extra string) (string, bool) {
+	switch variable {
+	case "implementation_name":
+		return e.ImplementationName, true
+	case "implementation_version":
+		return e.ImplementationVersion, true
+	case "os_name":
+		return e.OSName, true
+	case "platform_machine":
+		return e.PlatformMachine, true
+	case "platform_python_implementation":
+		return e.PlatformPythonImplementation, true
+	case "platform_release":
+		return e.PlatformRelease, true
+	case "platform_system":
+		return e.PlatformSystem, true
+	case "platform_version":
+		return e.PlatformVersion, true
+	case "python_full_version":
+		return e.PythonFullVersion, true
+	case "python_version":
+		return e.PythonVersion, true
+	case "sys_platform":
+		return e.SysPlatform, true
+	case "extra":
+		return extra, true
+	}
+
+	return "", false
+}
+
+// Marker is a parsed environment marker, such as
+// `python_version >= "3.8" and sys_platform == "linux"`.
+type Marker struct {
+	expression expression
+	text       string
+}
+
+// ParseMarker parses an environment marker.
+func ParseMarker(text string) (Marker, error) {
+	text = strings.TrimSpace(text)
+
+	tokens, err := tokenize(text)
+	if err != nil {
+		return Marker{}, fmt.Errorf("invalid marker '%s': %w", text, err)
+	}
+
+	p := &parser{tokens: tokens}
+	expression, err := p.or()
+	if err == nil && !p.done() {
+		err = fmt.Errorf("unexpected '%s'", p.peek().value)
+	}
+	if err != nil {
+		return Marker{}, fmt.Errorf("invalid marker '%s': %w", text, err)
+	}
+
+	return Marker{expression: expression, text: text}, nil
+}
+
+// String returns the marker as it was given.
+func (m Marker) String() string {
+	return m.text
+}
+
+// Evaluate reports whether the marker matches the environment. The extra is
+// the value of the "extra" marker variable, which is empty when evaluating
+// top-level requirements.
+func (m Marker) Evaluate(environment Environment, extra string) (bool, error) {
+	if m.expression == nil {
+		return true, nil
+	}
+
+	return m.expression.evaluate(environment, extra)
+}
+
+type expression interface {
+	evaluate(environment Environment, extra string) (bool, error)
+}
+
+type and struct{ left, right expression }
+
+func (e and) evaluate(environment Environment, extra string) (bool, error) {
+	left, err := e.left.evaluate(environment, extra)
+	if err != nil || !left {
+		return false, err
+	}
+
+	return e.right.evaluate(environment, extra)
+}
+
+type or struct{ left, right expression }
+
+func (e or) evaluate(environment Environment, extra string) (bool, error) {
+	left, err := e.left.evaluate(environment, extra)
+	if err != nil || left {
+		return left, err
+	}
+
+	return e.right.evaluate(environment, extra)
+}
+
+// operand is either a marker variable or a quoted string.
+type operand struct {
+	value    string
+	variable bool
+}
+
+func (o operand) resolve(environment Environment, extra string) string {
+	if !o.variable {
+		return o.value
+	}
+
+	value, _ := environment.lookup(o.value, extra)
+	return value
+}
+
+type comparison struct {
+	left, right operand
+	operator    string
+}
+
+func (c comparison) evaluate(environment Environment, extra string) (bool, error) {
+	left := c.left.resolve(environment, extra)
+	right := c.right.resolve(environment, extra)
+
+	// Extra names are compared in their normalized form, as described in
+	// PEP 685.
+	if (c.left.variable && c.left.value == "extra") || (c.right.variable && c.right.value == "extra") {
+		left, right = NormalizeName(left), NormalizeName(right)
+	}
+
+	switch c.operator {
+	case "in":
+		return strings.Contains(right, left), nil
+	case "not in":
+		return !strings.Contains(right, left), nil
+	}
+
+	if result, ok := compareVersions(left, c.operator, right); ok {
+		return result, nil
+	}
+
+	switch c.operator {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	case "<":
+		return left < right, nil
+	case "<=":
+		return left <= right, nil
+	case ">":
+		return left > right, nil
+	case ">=":
+		return left >= right, nil
+	}
+
+	return false, fmt.Errorf("operator '%s' cannot compare '%s' and '%s'", c.operator, left, right)
+}
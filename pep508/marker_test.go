@@ -0,0 +1,128 @@
+package pep508_test
+
+import (
+	"testing"
+
+	"github.com/paketo-buildpacks/pip-install/pep508"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMarker(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		environment pep508.Environment
+	)
+
+	it.Before(func() {
+		environment = pep508.Environment{
+			ImplementationName:           "cpython",
+			ImplementationVersion:        "3.12.4",
+			OSName:                       "posix",
+			PlatformMachine:              "aarch64",
+			PlatformPythonImplementation: "CPython",
+			PlatformRelease:              "6.1.0",
+			PlatformSystem:               "Linux",
+			PlatformVersion:              "#1 SMP",
+			PythonFullVersion:            "3.12.4",
+			PythonVersion:                "3.12",
+			SysPlatform:                  "linux",
+		}
+	})
+
+	context("Evaluate", func() {
+		it("evaluates markers against the environment", func() {
+			for marker, expected := range map[string]bool{
+				`python_version >= "3.8"`:                                                  true,
+				`python_version < "3.11"`:                                                  false,
+				`python_version > "3.9"`:                                                   true,
+				`python_version == "3.12"`:                                                 true,
+				`python_version != "3.12"`:                                                 false,
+				`python_version == "3.*"`:                                                  true,
+				`python_version != "3.11.*"`:                                               true,
+				`python_full_version ~= "3.12.0"`:                                          true,
+				`python_full_version ~= "3.11.0"`:                                          false,
+				`python_version ~= "3.10"`:                                                 true,
+				`python_full_version === "3.12.4"`:                                         true,
+				`"3.8" <= python_version`:                                                  true,
+				`sys_platform == "linux"`:                                                  true,
+				`sys_platform == 'win32'`:                                                  false,
+				`platform_machine == "aarch64"`:                                            true,
+				`platform_machine in "x86_64 aarch64"`:                                     true,
+				`platform_machine not in "x86_64 i686"`:                                    true,
+				`"arm" in platform_machine`:                                                false,
+				`implementation_name == "cpython" and os_name == "posix"`:                  true,
+				`implementation_name == "pypy" or platform_system == "Linux"`:              true,
+				`sys_platform == "win32" or sys_platform == "darwin"`:                      false,
+				`(sys_platform == "win32" or python_version > "3") and os_name == "posix"`: true,
+				`sys_platform == "linux" and (python_version < "3.9" or implementation_name == "pypy")`: false,
+				`platform_python_implementation == "CPython"`:                                           true,
+				`platform_release >= "6"`:                                                               true,
+				`extra == "test"`:                                                                       false,
+				`extra == ""`:                                                                           true,
+				`python_full_version >= "3.12.4rc1"`:                                                    true,
+				`python_full_version < "3.12.4"`:                                                        false,
+				`python_version > "3.12.0"`:                                                             false,
+				`implementation_version == "3.12.4+local"`:                                              false,
+				`sys.platform == "linux"`:                                                               true,
+				`os.name == "nt"`:                                                                       false,
+				`platform.machine == "aarch64"`:                                                         true,
+				`platform.version == "#1 SMP"`:                                                          true,
+				`platform.python_implementation == "CPython"`:                                           true,
+				`python_implementation != "CPython"`:                                                    false,
+			} {
+				parsed, err := pep508.ParseMarker(marker)
+				Expect(err).NotTo(HaveOccurred(), marker)
+
+				result, err := parsed.Evaluate(environment, "")
+				Expect(err).NotTo(HaveOccurred(), marker)
+				Expect(result).To(Equal(expected), marker)
+			}
+		})
+
+		it("compares extras in their normalized form", func() {
+			parsed, err := pep508.ParseMarker(`extra == "Server_Extras"`)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := parsed.Evaluate(environment, "server-extras")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeTrue())
+		})
+
+		it("returns an error for operators that cannot compare the values", func() {
+			parsed, err := pep508.ParseMarker(`sys_platform ~= "linux"`)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = parsed.Evaluate(environment, "")
+			Expect(err).To(MatchError("operator '~=' cannot compare 'linux' and 'linux'"))
+		})
+	})
+
+	context("ParseMarker", func() {
+		it("keeps the marker text", func() {
+			parsed, err := pep508.ParseMarker(` python_version >= "3.8" `)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.String()).To(Equal(`python_version >= "3.8"`))
+		})
+
+		it("returns an error for invalid markers", func() {
+			for marker, message := range map[string]string{
+				`python_version >`:                  "unexpected end of marker",
+				`python_version "3.8"`:              "expected an operator after 'python_version'",
+				`python_version >= "3.8`:            "unterminated string at position 18",
+				`unknown_variable == "x"`:           "unknown variable 'unknown_variable'",
+				`(python_version >= "3.8"`:          "expected ')'",
+				`python_version >= "3.8")`:          "unexpected ')'",
+				`python_version => "3.8"`:           "invalid operator at position 15",
+				`sys_platform not "linux"`:          "expected 'in' after 'not' at position 13",
+				`python_version >= "3.8" and`:       "unexpected end of marker",
+				`python_version >= "3.8" & os_name`: "unexpected character '&' at position 24",
+			} {
+				_, err := pep508.ParseMarker(marker)
+				Expect(err).To(MatchError(ContainSubstring(message)), marker)
+			}
+		})
+	})
+}
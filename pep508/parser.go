@@ -0,0 +1,208 @@
+package pep508
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenVariable tokenKind = iota
+	tokenString
+	tokenOperator
+	tokenAnd
+	tokenOr
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+var operators = []string{"===", "==", "!=", "<=", ">=", "~=", "<", ">"}
+
+func tokenize(text string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(text); {
+		c := text[i]
+
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, value: "("})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, value: ")"})
+			i++
+
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(text[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: text[i+1 : i+1+end]})
+			i += end + 2
+
+		case strings.ContainsRune("=!<>~", rune(c)):
+			operator := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(text[i:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("invalid operator at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, value: operator})
+			i += len(operator)
+
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(text) && (text[i] == '_' || text[i] == '.' || unicode.IsLetter(rune(text[i])) || unicode.IsDigit(rune(text[i]))) {
+				i++
+			}
+
+			switch word := text[start:i]; word {
+			case "and":
+				tokens = append(tokens, token{kind: tokenAnd, value: word})
+			case "or":
+				tokens = append(tokens, token{kind: tokenOr, value: word})
+			case "in":
+				tokens = append(tokens, token{kind: tokenOperator, value: word})
+			case "not":
+				rest := strings.TrimLeft(text[i:], " \t")
+				if !strings.HasPrefix(rest, "in") || (len(rest) > 2 && (unicode.IsLetter(rune(rest[2])) || rest[2] == '_')) {
+					return nil, fmt.Errorf("expected 'in' after 'not' at position %d", start)
+				}
+				i = len(text) - len(rest) + 2
+				tokens = append(tokens, token{kind: tokenOperator, value: "not in"})
+			default:
+				if canonical, ok := legacyVariables[word]; ok {
+					word = canonical
+				}
+				if _, ok := (Environment{}).lookup(word, ""); !ok {
+					return nil, fmt.Errorf("unknown variable '%s'", word)
+				}
+				tokens = append(tokens, token{kind: tokenVariable, value: word})
+			}
+
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+		}
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+func (p *parser) or() (expression, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for !p.done() && p.peek().kind == tokenOr {
+		p.position++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = or{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) and() (expression, error) {
+	left, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+
+	for !p.done() && p.peek().kind == tokenAnd {
+		p.position++
+		right, err := p.atom()
+		if err != nil {
+			return nil, err
+		}
+		left = and{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) atom() (expression, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of marker")
+	}
+
+	if p.peek().kind == tokenOpen {
+		p.position++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek().kind != tokenClose {
+			return nil, fmt.Errorf("expected ')'")
+		}
+		p.position++
+		return inner, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.done() || p.peek().kind != tokenOperator {
+		return nil, fmt.Errorf("expected an operator after '%s'", left.value)
+	}
+	operator := p.peek().value
+	p.position++
+
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	return comparison{left: left, right: right, operator: operator}, nil
+}
+
+func (p *parser) operand() (operand, error) {
+	if p.done() {
+		return operand{}, fmt.Errorf("unexpected end of marker")
+	}
+
+	next := p.peek()
+	switch next.kind {
+	case tokenVariable:
+		p.position++
+		return operand{value: next.value, variable: true}, nil
+	case tokenString:
+		p.position++
+		return operand{value: next.value}, nil
+	}
+
+	return operand{}, fmt.Errorf("expected a variable or string, found '%s'", next.value)
+}
@@ -0,0 +1,142 @@
+package pep508
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/paketo-buildpacks/pip-install/pep440"
+)
+
+// Requirement is a parsed dependency specification, such as
+// `requests[security] >= 2.8.1, == 2.8.* ; python_version < "2.7"` or
+// `pip @ https://github.com/pypa/pip/archive/1.3.1.zip`.
+type Requirement struct {
+	// Name is the project name as given.
+	Name string
+
+	// Extras holds the requested extras as given.
+	Extras []string
+
+	// Specifier holds the version specifier clauses. It is empty for direct
+	// references and requirements that accept any version.
+	Specifier pep440.SpecifierSet
+
+	// URL is the target of a direct reference, if any.
+	URL string
+
+	// Marker is the environment marker, which matches any environment when
+	// the requirement has none.
+	Marker Marker
+}
+
+var (
+	requirementPattern = regexp.MustCompile(`^([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)\s*(?:\[([^\]]*)\])?\s*(.*)$`)
+	namePattern        = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+)
+
+// ParseRequirement parses a dependency specification as described in
+// PEP 508.
+func ParseRequirement(text string) (Requirement, error) {
+	text = strings.TrimSpace(text)
+
+	match := requirementPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Requirement{}, fmt.Errorf("invalid requirement '%s': expected a project name", text)
+	}
+
+	requirement := Requirement{Name: match[1]}
+
+	if extras := strings.TrimSpace(match[2]); extras != "" {
+		for _, extra := range strings.Split(extras, ",") {
+			extra = strings.TrimSpace(extra)
+			if !namePattern.MatchString(extra) {
+				return Requirement{}, fmt.Errorf("invalid requirement '%s': invalid extra '%s'", text, extra)
+			}
+			requirement.Extras = append(requirement.Extras, extra)
+		}
+	}
+
+	rest := match[3]
+	var marker string
+
+	if location, found := strings.CutPrefix(rest, "@"); found {
+		location = strings.TrimSpace(location)
+
+		// The marker of a direct reference must be separated from the URL by
+		// whitespace, as ';' is valid in URLs.
+		url, remainder, _ := strings.Cut(location, " ")
+		remainder = strings.TrimSpace(remainder)
+		if url == "" {
+			return Requirement{}, fmt.Errorf("invalid requirement '%s': missing URL", text)
+		}
+		if remainder != "" {
+			after, found := strings.CutPrefix(remainder, ";")
+			if !found {
+				return Requirement{}, fmt.Errorf("invalid requirement '%s': unexpected '%s' after URL", text, remainder)
+			}
+			marker = after
+		}
+
+		requirement.URL = url
+	} else {
+		specifier, after, _ := strings.Cut(rest, ";")
+		marker = after
+
+		specifier = strings.TrimSpace(specifier)
+		if strings.HasPrefix(specifier, "(") && strings.HasSuffix(specifier, ")") {
+			specifier = specifier[1 : len(specifier)-1]
+		}
+
+		set, err := pep440.ParseSpecifierSet(specifier)
+		if err != nil {
+			return Requirement{}, fmt.Errorf("invalid requirement '%s': %w", text, err)
+		}
+		requirement.Specifier = set
+	}
+
+	if strings.TrimSpace(marker) != "" {
+		parsed, err := ParseMarker(marker)
+		if err != nil {
+			return Requirement{}, fmt.Errorf("invalid requirement '%s': %w", text, err)
+		}
+		requirement.Marker = parsed
+	}
+
+	return requirement, nil
+}
+
+// Pinned reports whether the requirement selects a single version with an
+// "==" clause without a wildcard, or with an "===" clause.
+func (r Requirement) Pinned() bool {
+	for _, specifier := range r.Specifier {
+		switch {
+		case specifier.Operator == "===":
+			return true
+		case specifier.Operator == "==" && !strings.HasSuffix(specifier.Version, ".*"):
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeName returns the normalized form of a distribution or extra name
+// as described in PEP 503 and PEP 685: runs of '-', '_' and '.' are replaced
+// by a single '-' and the result is lowercased.
+func NormalizeName(name string) string {
+	var builder strings.Builder
+	separator := false
+	for _, r := range strings.TrimSpace(name) {
+		if r == '-' || r == '_' || r == '.' {
+			separator = true
+			continue
+		}
+		if separator && builder.Len() > 0 {
+			builder.WriteRune('-')
+		}
+		separator = false
+		builder.WriteRune(r)
+	}
+
+	return strings.ToLower(builder.String())
+}
@@ -0,0 +1,93 @@
+package pep508_test
+
+import (
+	"testing"
+
+	"github.com/paketo-buildpacks/pip-install/pep508"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRequirement(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("ParseRequirement", func() {
+		it("parses named requirements", func() {
+			requirement, err := pep508.ParseRequirement(`requests[security, socks] >= 2.8.1, == 2.8.* ; python_version < "3.13"`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requirement.Name).To(Equal("requests"))
+			Expect(requirement.Extras).To(Equal([]string{"security", "socks"}))
+			Expect(requirement.Specifier.String()).To(Equal(">=2.8.1,==2.8.*"))
+			Expect(requirement.URL).To(BeEmpty())
+			Expect(requirement.Marker.String()).To(Equal(`python_version < "3.13"`))
+		})
+
+		it("parses specifiers in parentheses", func() {
+			requirement, err := pep508.ParseRequirement("name (>=1.0,<2)")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requirement.Specifier.String()).To(Equal(">=1.0,<2"))
+		})
+
+		it("parses direct references", func() {
+			requirement, err := pep508.ParseRequirement(`pip @ https://github.com/pypa/pip/archive/1.3.1.zip#sha256=abc ; os_name == "posix"`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requirement.Name).To(Equal("pip"))
+			Expect(requirement.URL).To(Equal("https://github.com/pypa/pip/archive/1.3.1.zip#sha256=abc"))
+			Expect(requirement.Specifier).To(BeEmpty())
+			Expect(requirement.Marker.String()).To(Equal(`os_name == "posix"`))
+		})
+
+		it("parses requirements without a specifier", func() {
+			requirement, err := pep508.ParseRequirement("Flask")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requirement.Name).To(Equal("Flask"))
+			Expect(requirement.Specifier).To(BeEmpty())
+			Expect(requirement.Marker.String()).To(BeEmpty())
+		})
+
+		it("returns an error for invalid requirements", func() {
+			for text, message := range map[string]string{
+				"./libs/shared":                    "expected a project name",
+				"https://example.com/flask.tar.gz": "missing operator",
+				"flask[bad extra]":                 "invalid extra 'bad extra'",
+				"flask >= 1.0 ; python_version":    "invalid marker",
+				"flask @":                          "missing URL",
+				"flask @ https://example.com x":    "unexpected 'x' after URL",
+				"flask =< 1.0":                     "invalid specifier",
+			} {
+				_, err := pep508.ParseRequirement(text)
+				Expect(err).To(MatchError(ContainSubstring(message)), text)
+			}
+		})
+	})
+
+	context("Pinned", func() {
+		it("reports whether the requirement selects a single version", func() {
+			for text, expected := range map[string]bool{
+				"flask==3.0.0":        true,
+				"flask===3.0.0":       true,
+				"flask>=3,==3.0.1":    true,
+				"flask==3.*":          false,
+				"flask>=3.0":          false,
+				"flask~=3.0":          false,
+				"flask":               false,
+				"flask @ https://x.y": false,
+			} {
+				requirement, err := pep508.ParseRequirement(text)
+				Expect(err).NotTo(HaveOccurred(), text)
+				Expect(requirement.Pinned()).To(Equal(expected), text)
+			}
+		})
+	})
+	context("NormalizeName", func() {
+		it("normalizes the name", func() {
+			Expect(pep508.NormalizeName("Friendly-Bard")).To(Equal("friendly-bard"))
+			Expect(pep508.NormalizeName("FRIENDLY_BARD")).To(Equal("friendly-bard"))
+			Expect(pep508.NormalizeName("friendly--_.bard")).To(Equal("friendly-bard"))
+			Expect(pep508.NormalizeName(" Zope.Interface ")).To(Equal("zope-interface"))
+		})
+	})
+}
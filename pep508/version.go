@@ -0,0 +1,30 @@
+package pep508
+
+import (
+	"strings"
+
+	"github.com/paketo-buildpacks/pip-install/pep440"
+)
+
+// compareVersions compares two values with the given operator as PEP 440
+// versions, as the packaging library does: the right-hand side forms a
+// specifier that must contain the left-hand side, with pre-releases allowed.
+// It reports false when either side is not a version, in which case the
+// caller falls back to comparing strings.
+func compareVersions(left, operator, right string) (bool, bool) {
+	if operator == "===" {
+		return strings.EqualFold(left, right), true
+	}
+
+	specifier, err := pep440.ParseSpecifier(operator + right)
+	if err != nil {
+		return false, false
+	}
+
+	version, err := pep440.Parse(left)
+	if err != nil {
+		return false, false
+	}
+
+	return specifier.Contains(version, true), true
+}
@@ -0,0 +1,206 @@
+package pipinstall
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+var hashFragmentPattern = regexp.MustCompile(`(^|&)(md5|sha1|sha224|sha256|sha384|sha512)=[0-9A-Fa-f]+`)
+
+// requirePinnedPolicy returns the policy for requirements that are not pinned
+// to a single version, given in BP_PIP_REQUIRE_PINNED: "warn", "fail", or an
+// empty string when the check is disabled. It also reports whether the check
+// extends to transitive dependencies, as enabled through
+// BP_PIP_REQUIRE_PINNED_TRANSITIVE.
+func requirePinnedPolicy() (string, bool, error) {
+	policy, exists := os.LookupEnv("BP_PIP_REQUIRE_PINNED")
+	if !exists {
+		return "", false, nil
+	}
+
+	if policy != "warn" && policy != "fail" {
+		return "", false, fmt.Errorf("invalid BP_PIP_REQUIRE_PINNED value '%s': must be one of 'warn' or 'fail'", policy)
+	}
+
+	value, exists := os.LookupEnv("BP_PIP_REQUIRE_PINNED_TRANSITIVE")
+	if !exists {
+		return policy, false, nil
+	}
+
+	transitive, err := strconv.ParseBool(value)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to parse BP_PIP_REQUIRE_PINNED_TRANSITIVE value '%s': %w", value, err)
+	}
+
+	return policy, transitive, nil
+}
+
+// checkPinned reports the requirements in lines that are not pinned: named
+// requirements without an "==" or "===" clause, unless a constraints file
+// pins them, and direct URLs without a hash. VCS requirements must be pinned
+// to a full commit SHA. Local projects and local archives are part of the
+// application and are always considered pinned, and the packages requested
+// through the build plan are exempt.
+//
+// Depending on the policy, unpinned requirements either fail the build
+// ("fail") or are logged as warnings ("warn"). It returns the normalized
+// names of the requirements and of the constrained projects, which are not
+// transitive dependencies.
+func (p PipInstallProcess) checkPinned(workingDir string, lines []RequirementLine, policy string) (map[string]bool, error) {
+	names := map[string]bool{}
+	constrained := map[string]bool{}
+
+	for _, line := range lines {
+		file, _, ok := line.optionValue("-c", "--constraint")
+		if line.Requirement != "" || !ok {
+			continue
+		}
+
+		constraints, err := ParseRequirements(workingDir, []string{file})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, constraint := range constraints {
+			requirement, err := pep508.ParseRequirement(constraint.Requirement)
+			if err == nil && requirement.Pinned() {
+				constrained[NormalizeName(requirement.Name)] = true
+				names[NormalizeName(requirement.Name)] = true
+			}
+		}
+	}
+
+	var unpinned []string
+	for _, line := range lines {
+		if line.Requirement == "" {
+			continue
+		}
+
+		name, reason := line.pinning(workingDir)
+		if name != "" {
+			names[NormalizeName(name)] = true
+		}
+
+		// The packages requested by other buildpacks are not under the
+		// control of the app, so they are exempt from its policy.
+		if reason == "" || line.File == buildPlanFile || (name != "" && constrained[NormalizeName(name)]) {
+			continue
+		}
+
+		message := fmt.Sprintf("%s: '%s' %s", line.Location(), redactCredentials(line.Requirement), reason)
+		if policy == "fail" {
+			unpinned = append(unpinned, message)
+			continue
+		}
+		p.logger.Subprocess("Warning: %s", message)
+	}
+
+	if len(unpinned) > 0 {
+		return nil, fmt.Errorf("requirements must be pinned (BP_PIP_REQUIRE_PINNED=fail):\n  %s", strings.Join(unpinned, "\n  "))
+	}
+
+	return names, nil
+}
+
+// checkTransitivePinned reports the distributions installed in targetPath
+// that were neither requested by name in the given requirements nor
+// installed from a direct reference, i.e. transitive dependencies whose
+// version was chosen by pip.
+func (p PipInstallProcess) checkTransitivePinned(targetPath string, names map[string]bool, policy string) error {
+	distributions, err := installedDistributions(targetPath)
+	if err != nil {
+		return fmt.Errorf("failed to list installed distributions: %w", err)
+	}
+
+	var unpinned []string
+	for _, dist := range distributions {
+		if dist.Direct || names[NormalizeName(dist.Name)] {
+			continue
+		}
+
+		message := fmt.Sprintf("'%s' %s was installed as a transitive dependency but is not pinned", dist.Name, dist.Version)
+		if policy == "fail" {
+			unpinned = append(unpinned, message)
+			continue
+		}
+		p.logger.Subprocess("Warning: %s", message)
+	}
+
+	if len(unpinned) > 0 {
+		return fmt.Errorf("transitive dependencies must be pinned (BP_PIP_REQUIRE_PINNED=fail):\n  %s", strings.Join(unpinned, "\n  "))
+	}
+
+	return nil
+}
+
+// pinning returns the project name of the requirement, when it is known,
+// and the reason it is not pinned, which is empty when it is.
+func (l RequirementLine) pinning(workingDir string) (string, string) {
+	if _, ok := l.LocalProject(workingDir); ok {
+		return "", ""
+	}
+
+	if vcs, ok := ParseVCSRequirement(l.Requirement); ok {
+		if !vcs.Pinned() {
+			return vcs.Name, "is not pinned to a full commit SHA"
+		}
+		return vcs.Name, ""
+	}
+
+	_, _, hashed := l.optionValue("--hash")
+
+	if requirement, err := pep508.ParseRequirement(l.Requirement); err == nil {
+		switch {
+		case requirement.URL != "":
+			if !hashed && !hasHashFragment(requirement.URL) && !localURL(requirement.URL) {
+				return requirement.Name, "is a direct URL without a hash"
+			}
+		case !requirement.Pinned():
+			return requirement.Name, "is not pinned with '==' or '==='"
+		}
+		return requirement.Name, ""
+	}
+
+	// The remaining requirements are given as URLs or paths of archives.
+	location, _, _ := strings.Cut(l.Requirement, " ")
+	name := archiveName(location)
+	if !hashed && !hasHashFragment(location) && !localURL(location) {
+		return name, "is a direct URL without a hash"
+	}
+
+	return name, ""
+}
+
+func hasHashFragment(location string) bool {
+	_, fragment, _ := strings.Cut(location, "#")
+	return hashFragmentPattern.MatchString(fragment)
+}
+
+// localURL reports whether the location refers to a local file, either as a
+// path or as a file: URL.
+func localURL(location string) bool {
+	return strings.HasPrefix(location, "file:") || !strings.Contains(location, "://")
+}
+
+// archiveName returns the project name of an archive URL or path, taken from
+// its #egg= fragment or, for wheels, from its file name.
+func archiveName(location string) string {
+	location, fragment, _ := strings.Cut(location, "#")
+	if values, err := url.ParseQuery(fragment); err == nil && values.Get("egg") != "" {
+		return values.Get("egg")
+	}
+
+	if base := path.Base(location); strings.HasSuffix(base, ".whl") {
+		name, _, _ := strings.Cut(base, "-")
+		return name
+	}
+
+	return ""
+}
@@ -0,0 +1,70 @@
+package pipinstall
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+// buildPlanFile is the File of the requirement lines of the packages
+// requested through the build plan.
+const buildPlanFile = "build plan"
+
+// planPackages returns the packages that other buildpacks request through
+// the `packages` metadata of their site-packages build plan requirements,
+// merged across the entries in the order they are requested. Each package is
+// a requirement specifier, such as "gunicorn>=21". Packages requested by more
+// than one buildpack are only returned once.
+func planPackages(entries []packit.BuildpackPlanEntry) ([]string, error) {
+	var packages []string
+	seen := map[string]bool{}
+
+	for _, entry := range entries {
+		if entry.Name != SitePackages {
+			continue
+		}
+
+		value, ok := entry.Metadata["packages"]
+		if !ok {
+			continue
+		}
+
+		var requested []string
+		switch value := value.(type) {
+		case []string:
+			requested = value
+		case []interface{}:
+			for _, item := range value {
+				name, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid build plan metadata 'packages' of '%s': must be a list of requirement specifiers", SitePackages)
+				}
+				requested = append(requested, name)
+			}
+		default:
+			return nil, fmt.Errorf("invalid build plan metadata 'packages' of '%s': must be a list of requirement specifiers", SitePackages)
+		}
+
+		for _, specifier := range requested {
+			specifier = strings.TrimSpace(specifier)
+
+			requirement, err := pep508.ParseRequirement(specifier)
+			if err != nil {
+				return nil, fmt.Errorf("invalid package '%s' requested through the build plan: %w", specifier, err)
+			}
+
+			if requirement.URL != "" {
+				return nil, fmt.Errorf("invalid package '%s' requested through the build plan: direct references are not supported", specifier)
+			}
+
+			if !seen[specifier] {
+				seen[specifier] = true
+				packages = append(packages, specifier)
+			}
+		}
+	}
+
+	return packages, nil
+}
@@ -0,0 +1,192 @@
+package pipinstall
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+// systemDependency is a system library or tool that a project needs in
+// order to be built from source. It is satisfied when any of its Commands is
+// found on the PATH, or when all of its Headers are found.
+type systemDependency struct {
+	Commands []string
+	Headers  []string
+
+	// Packages names the system packages that provide the dependency.
+	Packages string
+}
+
+func (d systemDependency) satisfied(toolchain Toolchain) bool {
+	for _, command := range d.Commands {
+		if toolchain.HasCommand(command) {
+			return true
+		}
+	}
+
+	if len(d.Headers) == 0 {
+		return false
+	}
+
+	for _, header := range d.Headers {
+		if !toolchain.HasHeader(header) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (d systemDependency) String() string {
+	var needs []string
+	if len(d.Commands) > 0 {
+		needs = append(needs, strings.Join(d.Commands, " or "))
+	}
+	if len(d.Headers) > 0 {
+		needs = append(needs, strings.Join(d.Headers, " and "))
+	}
+
+	return fmt.Sprintf("%s (provided by %s)", strings.Join(needs, " or "), d.Packages)
+}
+
+// nativeProjects are well-known projects that do not publish wheels for
+// Linux and are therefore always compiled, mapped to the system libraries
+// and tools their build needs.
+var nativeProjects = map[string][]systemDependency{
+	"psycopg2": {
+		{Commands: []string{"pg_config"}, Packages: "libpq-dev"},
+	},
+	"mysqlclient": {
+		{Commands: []string{"mysql_config", "mariadb_config"}, Packages: "libmysqlclient-dev or libmariadb-dev"},
+	},
+	"python-ldap": {
+		{Headers: []string{"lber.h", "ldap.h"}, Packages: "libldap2-dev"},
+		{Headers: []string{"sasl/sasl.h"}, Packages: "libsasl2-dev"},
+	},
+	"pycairo": {
+		{Headers: []string{"cairo/cairo.h"}, Packages: "libcairo2-dev"},
+	},
+	"mod-wsgi": {
+		{Commands: []string{"apxs", "apxs2"}, Packages: "apache2-dev"},
+	},
+	"uwsgi": nil,
+}
+
+// sourceBuild is a project that pip will build from source.
+type sourceBuild struct {
+	// Name is the project name.
+	Name string
+
+	// Origin is where the project is requested, either the location of a
+	// requirement or the file name of an sdist.
+	Origin string
+}
+
+// sourceBuilds returns the projects that pip will build from source: the
+// sdists in the local find-links directories that have no wheel of the same
+// project next to them nor a cached built wheel, and the requirements of the
+// nativeProjects that have no such wheel either. Only the wheels whose tags
+// the interpreter described by the environment accepts are counted.
+func sourceBuilds(cachePath string, environment pep508.Environment, lines []RequirementLine, sdists, localDirs []string) ([]sourceBuild, error) {
+	tag := pythonTag(environment)
+	wheels := localWheelNames(localDirs, environment)
+
+	var builds []sourceBuild
+	seen := map[string]bool{}
+
+	for _, sdist := range sdists {
+		name := NormalizeName(sdistName(sdist))
+		if wheels[name] {
+			continue
+		}
+
+		_, cached, err := cachedWheelDir(cachePath, tag, sdist)
+		if err != nil {
+			return nil, err
+		}
+
+		// A cached wheel of any sdist of the project is preferred by pip.
+		if cached {
+			wheels[name] = true
+			continue
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			builds = append(builds, sourceBuild{Name: sdistName(sdist), Origin: filepath.Base(sdist)})
+		}
+	}
+
+	for _, line := range lines {
+		if line.Requirement == "" {
+			continue
+		}
+
+		requirement, err := pep508.ParseRequirement(line.Requirement)
+		if err != nil || requirement.URL != "" {
+			continue
+		}
+
+		name := NormalizeName(requirement.Name)
+		if _, ok := nativeProjects[name]; !ok || wheels[name] || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		builds = append(builds, sourceBuild{Name: requirement.Name, Origin: line.Location()})
+	}
+
+	return builds, nil
+}
+
+// checkToolchain checks that the C compiler, the Python headers and the
+// system dependencies needed to build the projects from source are
+// available. Missing dependencies of the nativeProjects, which cannot be
+// installed without them, fail the build with a list of what is missing,
+// while those of other projects, which may not contain native code, are
+// logged as warnings.
+func (p PipInstallProcess) checkToolchain(builds []sourceBuild) error {
+	p.logger.Subprocess("Checking the native toolchain for %d requirements built from source", len(builds))
+
+	toolchain, err := p.toolchainProcess.Execute()
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, build := range builds {
+		var needs []string
+		if toolchain.Compiler == "" {
+			needs = append(needs, "a C compiler (cc, gcc or clang, or set CC)")
+		}
+		if toolchain.PythonHeaders == "" {
+			needs = append(needs, "the Python headers (Python.h)")
+		}
+
+		dependencies, native := nativeProjects[NormalizeName(build.Name)]
+		for _, dependency := range dependencies {
+			if !dependency.satisfied(toolchain) {
+				needs = append(needs, dependency.String())
+			}
+		}
+
+		if len(needs) == 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("'%s' (%s) is built from source and needs %s", build.Name, build.Origin, strings.Join(needs, ", "))
+		if native {
+			missing = append(missing, message)
+			continue
+		}
+		p.logger.Subprocess("Warning: %s", message)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing native build dependencies:\n  %s", strings.Join(missing, "\n  "))
+	}
+
+	return nil
+}
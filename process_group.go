@@ -0,0 +1,113 @@
+package pipinstall
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// processGroupWaitDelay bounds how long an execution waits for its output to
+// be closed once its process group has been killed, in case a descendant left
+// the group and still holds it open.
+const processGroupWaitDelay = 5 * time.Second
+
+// contextExecutable is implemented by executables that can be bound to a
+// context, which kills the processes they run when it is done.
+type contextExecutable interface {
+	WithContext(ctx context.Context) Executable
+}
+
+// withContext binds the executable to the ctx when it supports it.
+func withContext(executable Executable, ctx context.Context) Executable {
+	if bindable, ok := executable.(contextExecutable); ok {
+		return bindable.WithContext(ctx)
+	}
+	return executable
+}
+
+// ProcessGroupExecutable is an Executable that runs each execution in a
+// process group of its own, so that the whole group, including the build
+// backends and compilers spawned by pip, is killed once the context the
+// executable is bound to is done.
+type ProcessGroupExecutable struct {
+	name string
+	ctx  context.Context
+}
+
+// NewProcessGroupExecutable returns an instance of a ProcessGroupExecutable
+// given the name of, or the path to, the executable. Like a
+// pexec.Executable, a name is looked up on the PATH of the execution.
+func NewProcessGroupExecutable(name string) ProcessGroupExecutable {
+	return ProcessGroupExecutable{
+		name: name,
+		ctx:  context.Background(),
+	}
+}
+
+// WithContext returns a copy of the executable bound to the ctx.
+func (e ProcessGroupExecutable) WithContext(ctx context.Context) Executable {
+	e.ctx = ctx
+	return e
+}
+
+// Execute invokes the executable with a set of Execution arguments.
+func (e ProcessGroupExecutable) Execute(execution pexec.Execution) error {
+	executable, err := lookPath(e.name, execution.Env)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(e.ctx, executable, execution.Args...)
+
+	if execution.Dir != "" {
+		cmd.Dir = execution.Dir
+	}
+
+	if len(execution.Env) > 0 {
+		cmd.Env = execution.Env
+	}
+
+	cmd.Stdout = execution.Stdout
+	cmd.Stderr = execution.Stderr
+	cmd.Stdin = execution.Stdin
+	cmd.WaitDelay = processGroupWaitDelay
+
+	setProcessGroup(cmd)
+
+	return cmd.Run()
+}
+
+// lookPath returns the path to the named executable, looked up on the PATH
+// given in env, or on the PATH of the buildpack when env does not set one.
+func lookPath(name string, env []string) (string, error) {
+	if strings.Contains(name, string(filepath.Separator)) {
+		return name, nil
+	}
+
+	path := os.Getenv("PATH")
+	for _, variable := range env {
+		if value, ok := strings.CutPrefix(variable, "PATH="); ok && value != "" {
+			path = value
+		}
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			dir = "."
+		}
+
+		candidate := filepath.Join(dir, name)
+		info, err := os.Stat(candidate)
+		if err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("exec: %q: %w", name, exec.ErrNotFound)
+}
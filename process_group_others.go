@@ -0,0 +1,10 @@
+//go:build !unix
+
+package pipinstall
+
+import "os/exec"
+
+// setProcessGroup leaves the command in the process group of the buildpack,
+// as process groups are not supported, so that its cancellation only kills
+// the command.
+func setProcessGroup(cmd *exec.Cmd) {}
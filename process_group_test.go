@@ -0,0 +1,85 @@
+package pipinstall_test
+
+import (
+	"bytes"
+	gocontext "context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testProcessGroupExecutable(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect     = NewWithT(t).Expect
+		Eventually = NewWithT(t).Eventually
+
+		binDir string
+	)
+
+	it.Before(func() {
+		binDir = t.TempDir()
+
+		Expect(os.WriteFile(filepath.Join(binDir, "some-executable"), []byte("#!/bin/sh\necho \"$@\"\n"), 0700)).To(Succeed())
+	})
+
+	context("Execute", func() {
+		it("runs the executable found on the PATH of the execution", func() {
+			stdout := bytes.NewBuffer(nil)
+
+			err := pipinstall.NewProcessGroupExecutable("some-executable").Execute(pexec.Execution{
+				Args:   []string{"some-arg"},
+				Env:    append(os.Environ(), "PATH="+binDir),
+				Stdout: stdout,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(Equal("some-arg\n"))
+		})
+
+		context("when the context is done", func() {
+			it("kills the process group of the execution", func() {
+				ctx, cancel := gocontext.WithTimeout(t.Context(), 100*time.Millisecond)
+				defer cancel()
+
+				pidFile := filepath.Join(t.TempDir(), "pid")
+				executable := pipinstall.NewProcessGroupExecutable("/bin/sh").WithContext(ctx)
+
+				start := time.Now()
+				err := executable.Execute(pexec.Execution{
+					Args: []string{"-c", "sleep 30 & echo $! > " + pidFile + "; wait"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("killed")))
+				Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+
+				pid, err := os.ReadFile(pidFile)
+				Expect(err).NotTo(HaveOccurred())
+				// The killed process may linger as a zombie until it is reaped.
+				Eventually(func() string {
+					stat, err := os.ReadFile(filepath.Join("/proc", string(bytes.TrimSpace(pid)), "stat"))
+					if err != nil {
+						return "gone"
+					}
+					return string(stat)
+				}).Should(Or(Equal("gone"), ContainSubstring(") Z ")))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the executable is not found", func() {
+				it("returns an error", func() {
+					err := pipinstall.NewProcessGroupExecutable("some-executable").Execute(pexec.Execution{
+						Env: []string{"PATH=" + t.TempDir()},
+					})
+					Expect(err).To(MatchError(ContainSubstring(`"some-executable": executable file not found in $PATH`)))
+				})
+			})
+		})
+	})
+}
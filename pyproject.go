@@ -0,0 +1,150 @@
+package pipinstall
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+// PyProject is the subset of a PEP 621 pyproject.toml file that describes the
+// dependencies of a project.
+type PyProject struct {
+	// Defined denotes that the file contains a [project] table.
+	Defined bool
+
+	// Name is the name of the project.
+	Name string
+
+	// Dependencies are the PEP 508 requirements listed in
+	// [project] dependencies.
+	Dependencies []string
+
+	// OptionalDependencies are the PEP 508 requirements listed in
+	// [project.optional-dependencies], keyed by extra name.
+	OptionalDependencies map[string][]string
+
+	// Dynamic are the fields listed in [project] dynamic, whose values are
+	// only provided by the build backend.
+	Dynamic []string
+}
+
+// ParsePyProject reads the pyproject.toml file at the given path.
+func ParsePyProject(path string) (PyProject, error) {
+	var content struct {
+		Project struct {
+			Name                 string              `toml:"name"`
+			Dependencies         []string            `toml:"dependencies"`
+			OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+			Dynamic              []string            `toml:"dynamic"`
+		} `toml:"project"`
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return PyProject{}, err
+	}
+	defer file.Close()
+
+	metadata, err := toml.NewDecoder(file).Decode(&content)
+	if err != nil {
+		return PyProject{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return PyProject{
+		Defined:              metadata.IsDefined("project"),
+		Name:                 content.Project.Name,
+		Dependencies:         content.Project.Dependencies,
+		OptionalDependencies: content.Project.OptionalDependencies,
+		Dynamic:              content.Project.Dynamic,
+	}, nil
+}
+
+// IsDynamic reports whether the field is listed in [project] dynamic.
+func (p PyProject) IsDynamic(field string) bool {
+	for _, dynamic := range p.Dynamic {
+		if dynamic == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Requirements returns the dependencies of the project together with the
+// optional dependencies of each of the given extras. Extra names are
+// normalized as described in PEP 685. Extras cannot be given when the
+// optional dependencies are dynamic.
+func (p PyProject) Requirements(extras []string) ([]string, error) {
+	if len(extras) > 0 && p.IsDynamic("optional-dependencies") {
+		return nil, fmt.Errorf("pyproject.toml declares its optional dependencies as dynamic, so those of extra(s) '%s' cannot be read", strings.Join(extras, "', '"))
+	}
+
+	optional := map[string][]string{}
+	for extra, dependencies := range p.OptionalDependencies {
+		name := NormalizeName(extra)
+		optional[name] = append(optional[name], dependencies...)
+	}
+
+	requirements := append([]string{}, p.Dependencies...)
+
+	var missing []string
+	for _, extra := range extras {
+		dependencies, ok := optional[NormalizeName(extra)]
+		if !ok {
+			missing = append(missing, extra)
+			continue
+		}
+		requirements = append(requirements, dependencies...)
+	}
+
+	if len(missing) > 0 {
+		var available []string
+		for extra := range optional {
+			available = append(available, extra)
+		}
+		sort.Strings(available)
+
+		return nil, fmt.Errorf("pyproject.toml does not define optional dependencies for extra(s): '%s' (available: '%s')",
+			strings.Join(missing, "', '"), strings.Join(available, "', '"))
+	}
+
+	return requirements, nil
+}
+
+// NormalizeName returns the normalized form of a distribution or extra name,
+// see pep508.NormalizeName.
+func NormalizeName(name string) string {
+	return pep508.NormalizeName(name)
+}
+
+// pyProjectEnabled reports whether installing the dependencies declared in a
+// PEP 621 pyproject.toml has been enabled through BP_PIP_PYPROJECT.
+func pyProjectEnabled() (bool, error) {
+	value, exists := os.LookupEnv("BP_PIP_PYPROJECT")
+	if !exists {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse BP_PIP_PYPROJECT value '%s': %w", value, err)
+	}
+
+	return enabled, nil
+}
+
+// pyProjectExtras returns the extras listed in BP_PIP_EXTRAS.
+func pyProjectExtras() []string {
+	var extras []string
+	for _, extra := range strings.Split(os.Getenv("BP_PIP_EXTRAS"), ",") {
+		if extra = strings.TrimSpace(extra); extra != "" {
+			extras = append(extras, extra)
+		}
+	}
+
+	return extras
+}
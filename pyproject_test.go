@@ -0,0 +1,163 @@
+package pipinstall_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testPyProject(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		path = filepath.Join(t.TempDir(), "pyproject.toml")
+		Expect(os.WriteFile(path, []byte(`
+[build-system]
+requires = ["setuptools"]
+
+[project]
+name = "some-app"
+dependencies = [
+  "flask>=3",
+  "gunicorn; sys_platform == 'linux'",
+]
+
+[project.optional-dependencies]
+Web_Server = ["uvicorn[standard]"]
+metrics = ["prometheus-client"]
+`), 0600)).To(Succeed())
+	})
+
+	context("ParsePyProject", func() {
+		it("parses the [project] table", func() {
+			pyProject, err := pipinstall.ParsePyProject(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pyProject).To(Equal(pipinstall.PyProject{
+				Defined:      true,
+				Name:         "some-app",
+				Dependencies: []string{"flask>=3", "gunicorn; sys_platform == 'linux'"},
+				OptionalDependencies: map[string][]string{
+					"Web_Server": {"uvicorn[standard]"},
+					"metrics":    {"prometheus-client"},
+				},
+			}))
+		})
+
+		context("when fields are dynamic", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte("[project]\nname = \"some-app\"\ndynamic = [\"version\", \"dependencies\"]\n"), 0600)).To(Succeed())
+			})
+
+			it("lists them", func() {
+				pyProject, err := pipinstall.ParsePyProject(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pyProject.Dynamic).To(Equal([]string{"version", "dependencies"}))
+				Expect(pyProject.IsDynamic("dependencies")).To(BeTrue())
+				Expect(pyProject.IsDynamic("optional-dependencies")).To(BeFalse())
+			})
+		})
+
+		context("when there is no [project] table", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte("[tool.poetry]\nname = \"some-app\"\n"), 0600)).To(Succeed())
+			})
+
+			it("is not defined", func() {
+				pyProject, err := pipinstall.ParsePyProject(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pyProject.Defined).To(BeFalse())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the file is not valid TOML", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte("[project"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := pipinstall.ParsePyProject(path)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse")))
+				})
+			})
+
+			context("when the file does not exist", func() {
+				it("returns an error", func() {
+					_, err := pipinstall.ParsePyProject(filepath.Join(t.TempDir(), "pyproject.toml"))
+					Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+				})
+			})
+		})
+	})
+
+	context("Requirements", func() {
+		var pyProject pipinstall.PyProject
+
+		it.Before(func() {
+			var err error
+			pyProject, err = pipinstall.ParsePyProject(path)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("returns the dependencies", func() {
+			requirements, err := pyProject.Requirements(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requirements).To(Equal([]string{"flask>=3", "gunicorn; sys_platform == 'linux'"}))
+		})
+
+		it("includes the optional dependencies of normalized extras", func() {
+			requirements, err := pyProject.Requirements([]string{"web-server", "Metrics"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requirements).To(Equal([]string{
+				"flask>=3",
+				"gunicorn; sys_platform == 'linux'",
+				"uvicorn[standard]",
+				"prometheus-client",
+			}))
+		})
+
+		context("when the optional dependencies are dynamic", func() {
+			it.Before(func() {
+				pyProject.Dynamic = []string{"optional-dependencies"}
+			})
+
+			it("returns the dependencies", func() {
+				requirements, err := pyProject.Requirements(nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(requirements).To(Equal([]string{"flask>=3", "gunicorn; sys_platform == 'linux'"}))
+			})
+
+			it("returns an error when extras are given", func() {
+				_, err := pyProject.Requirements([]string{"web-server"})
+				Expect(err).To(MatchError("pyproject.toml declares its optional dependencies as dynamic, so those of extra(s) 'web-server' cannot be read"))
+			})
+		})
+
+		context("when an extra is not defined", func() {
+			it("returns an error", func() {
+				_, err := pyProject.Requirements([]string{"web-server", "docs"})
+				Expect(err).To(MatchError("pyproject.toml does not define optional dependencies for extra(s): 'docs' (available: 'metrics', 'web-server')"))
+			})
+		})
+	})
+
+	context("NormalizeName", func() {
+		it("normalizes names as described in PEP 503", func() {
+			Expect(pipinstall.NormalizeName("Friendly-Bard")).To(Equal("friendly-bard"))
+			Expect(pipinstall.NormalizeName("FRIENDLY_BARD")).To(Equal("friendly-bard"))
+			Expect(pipinstall.NormalizeName("friendly.bard")).To(Equal("friendly-bard"))
+			Expect(pipinstall.NormalizeName("friendly--_.bard")).To(Equal("friendly-bard"))
+			Expect(pipinstall.NormalizeName("zope.interface")).To(Equal("zope-interface"))
+		})
+	})
+}
@@ -0,0 +1,29 @@
+package pipinstall
+
+import (
+	"regexp"
+	"strings"
+)
+
+// userinfoPattern matches the scheme and the user information of the URLs in
+// a text.
+var userinfoPattern = regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.-]*://)([^/?#@\s]*)@`)
+
+// redactCredentials returns the text with the credentials of the URLs it
+// contains removed, so that it can be logged and recorded. Over HTTP(S) the
+// whole user information is removed, as access tokens are commonly given as
+// the username; over other schemes, such as SSH, only the password is.
+func redactCredentials(text string) string {
+	return userinfoPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := userinfoPattern.FindStringSubmatch(match)
+		scheme, userinfo := parts[1], parts[2]
+
+		lower := strings.ToLower(scheme)
+		if strings.HasSuffix(lower, "http://") || strings.HasSuffix(lower, "https://") {
+			return scheme
+		}
+
+		username, _, _ := strings.Cut(userinfo, ":")
+		return scheme + username + "@"
+	})
+}
@@ -0,0 +1,426 @@
+package pipinstall
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+// RequirementLine is a single logical line of a pip requirements file, after
+// comments have been stripped and continuation lines have been joined.
+type RequirementLine struct {
+	// File is the path of the requirements file that contains the line,
+	// relative to the working directory.
+	File string
+
+	// Line is the line number on which the logical line starts.
+	Line int
+
+	// Requirement is the requirement specifier, URL or path. It is empty for
+	// lines that only carry options, such as --index-url.
+	Requirement string
+
+	// Editable denotes that the requirement was given with -e/--editable.
+	Editable bool
+
+	// Options holds the remaining options of the line, such as --hash on a
+	// requirement line or --index-url on an option line.
+	Options []string
+}
+
+// Location returns the file:line location of the line.
+func (l RequirementLine) Location() string {
+	if l.Line == 0 {
+		return l.File
+	}
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// String returns the line in a form that pip can read back from a
+// requirements file.
+func (l RequirementLine) String() string {
+	var fields []string
+	if l.Editable {
+		fields = append(fields, "--editable")
+	}
+	if l.Requirement != "" {
+		fields = append(fields, l.Requirement)
+	}
+
+	return strings.Join(append(fields, l.Options...), " ")
+}
+
+var (
+	commentPattern     = regexp.MustCompile(`(^|\s+)#.*$`)
+	environmentPattern = regexp.MustCompile(`\$\{([A-Z0-9_]+)\}`)
+
+	// directReferencePattern matches PEP 508 direct references such as
+	// "name[extra] @ file:///path".
+	directReferencePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*\s*(?:\[[^\]]*\])?\s*@\s*(\S+)`)
+)
+
+// ParseRequirements reads the given requirements files, relative to the
+// workingDir, and returns their lines in the order pip would read them.
+// Nested requirements files referenced with -r/--requirement are expanded in
+// place. References to constraints files and find-links directories are
+// rewritten to absolute paths, so the lines can be written out to a file in
+// another directory.
+//
+// Files that do not exist are skipped, leaving it to pip to report them, and
+// so are nested requirements files given as URLs, which pip fetches itself.
+func ParseRequirements(workingDir string, files []string) ([]RequirementLine, error) {
+	var lines []RequirementLine
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(workingDir, file)
+		}
+
+		if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		parsed, err := parseRequirementsFile(workingDir, file, nil)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, parsed...)
+	}
+
+	return lines, nil
+}
+
+func parseRequirementsFile(workingDir, file string, parents []string) ([]RequirementLine, error) {
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, file)
+	}
+
+	for _, parent := range parents {
+		if parent == path {
+			return nil, fmt.Errorf("requirements file '%s' includes itself", file)
+		}
+	}
+
+	content, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read requirements file: %w", err)
+	}
+	defer content.Close()
+
+	name, err := filepath.Rel(workingDir, path)
+	if err != nil || strings.HasPrefix(name, "..") {
+		name = path
+	}
+
+	var (
+		lines   []RequirementLine
+		logical string
+		start   int
+		number  int
+	)
+
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		number++
+		text := scanner.Text()
+
+		if logical == "" {
+			start = number
+		}
+
+		if strings.HasSuffix(text, `\`) {
+			logical += strings.TrimSuffix(text, `\`)
+			continue
+		}
+		logical += text
+
+		line, err := parseRequirementLine(logical)
+		logical = ""
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, start, err)
+		}
+
+		if line == nil {
+			continue
+		}
+
+		line.File = name
+		line.Line = start
+
+		if include, ok := line.include(); ok {
+			// Remote requirements files are left to pip to fetch.
+			if strings.Contains(include, "://") {
+				lines = append(lines, *line)
+				continue
+			}
+
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(path), include)
+			}
+
+			nested, err := parseRequirementsFile(workingDir, include, append(parents, path))
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, nested...)
+			continue
+		}
+
+		line.absolutePaths(filepath.Dir(path))
+		lines = append(lines, *line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read requirements file: %w", err)
+	}
+
+	return lines, nil
+}
+
+func parseRequirementLine(text string) (*RequirementLine, error) {
+	text = commentPattern.ReplaceAllString(text, "")
+	text = environmentPattern.ReplaceAllStringFunc(text, func(match string) string {
+		value, ok := os.LookupEnv(environmentPattern.FindStringSubmatch(match)[1])
+		if !ok {
+			return match
+		}
+		return value
+	})
+
+	tokens := strings.Fields(text)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	line := &RequirementLine{}
+
+	// Like pip, everything up to the first option is the requirement, which
+	// allows specifiers such as "foo >= 1.0 ; python_version < '3.9'".
+	var requirement []string
+	for len(tokens) > 0 && !strings.HasPrefix(tokens[0], "-") {
+		requirement = append(requirement, tokens[0])
+		tokens = tokens[1:]
+	}
+
+	if len(requirement) == 0 {
+		switch option, value, found := splitOption(tokens[0]); option {
+		case "-e", "--editable":
+			if !found {
+				if len(tokens) < 2 {
+					return nil, fmt.Errorf("%s requires an argument", option)
+				}
+				value = tokens[1]
+				tokens = tokens[1:]
+			}
+			line.Editable = true
+			requirement = []string{value}
+			tokens = tokens[1:]
+		}
+	}
+
+	line.Requirement = strings.Join(requirement, " ")
+	if len(tokens) > 0 {
+		line.Options = tokens
+	}
+
+	return line, nil
+}
+
+// splitOption splits a token such as "--requirement=file.txt" or
+// "-rfile.txt" into its option name and value.
+func splitOption(token string) (option, value string, found bool) {
+	if strings.HasPrefix(token, "--") {
+		option, value, found = strings.Cut(token, "=")
+		return option, value, found
+	}
+
+	if len(token) > 2 {
+		return token[:2], token[2:], true
+	}
+
+	return token, "", false
+}
+
+// optionValue returns the value of the first of the given options found on
+// the line, along with its index in Options.
+func (l RequirementLine) optionValue(names ...string) (string, int, bool) {
+	for i := 0; i < len(l.Options); i++ {
+		option, value, found := splitOption(l.Options[i])
+		for _, name := range names {
+			if option != name {
+				continue
+			}
+			if !found {
+				if i+1 >= len(l.Options) {
+					return "", i, false
+				}
+				value = l.Options[i+1]
+			}
+			return value, i, true
+		}
+	}
+
+	return "", 0, false
+}
+
+func (l RequirementLine) include() (string, bool) {
+	if l.Requirement != "" {
+		return "", false
+	}
+
+	value, _, ok := l.optionValue("-r", "--requirement")
+	return value, ok
+}
+
+func (l *RequirementLine) absolutePaths(dir string) {
+	if l.Requirement != "" {
+		return
+	}
+
+	for _, names := range [][]string{{"-c", "--constraint"}, {"-f", "--find-links"}} {
+		value, index, ok := l.optionValue(names...)
+		if !ok || value == "" || filepath.IsAbs(value) || strings.Contains(value, ":") {
+			continue
+		}
+
+		// Like pip, only rewrite relative find-links that exist next to the
+		// requirements file.
+		path := filepath.Join(dir, value)
+		if names[0] == "-f" {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+		}
+
+		options := append([]string{}, l.Options...)
+		if _, _, found := splitOption(options[index]); found {
+			options[index] = names[1] + "=" + path
+		} else {
+			options[index+1] = path
+		}
+		l.Options = options
+	}
+}
+
+// LocalProject returns the absolute path of the local project directory that
+// the requirement refers to, if any. Local projects are given as paths, such
+// as "." or "./libs/shared", or as file: URLs, optionally as the target of a
+// direct reference, and contain a setup.py or pyproject.toml. Local archives
+// such as wheels are not considered projects.
+func (l RequirementLine) LocalProject(workingDir string) (string, bool) {
+	location, _, _ := strings.Cut(l.Requirement, ";")
+	location = strings.TrimSpace(location)
+
+	if match := directReferencePattern.FindStringSubmatch(location); match != nil {
+		location = match[1]
+	}
+
+	var path string
+	switch {
+	case strings.HasPrefix(location, "file:"):
+		parsed, err := url.Parse(location)
+		if err != nil {
+			return "", false
+		}
+		path = parsed.Path
+		if path == "" {
+			path = parsed.Opaque
+		}
+
+	case strings.Contains(location, "://"):
+		return "", false
+
+	case strings.HasPrefix(location, ".") || strings.Contains(location, "/"):
+		path = location
+
+	default:
+		return "", false
+	}
+
+	// Strip any extras, e.g. "./libs/shared[server]".
+	if index := strings.LastIndex(path, "["); index > 0 && strings.HasSuffix(path, "]") {
+		path = path[:index]
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	for _, marker := range []string{"pyproject.toml", "setup.py"} {
+		if info, err := os.Stat(filepath.Join(path, marker)); err == nil && !info.IsDir() {
+			return filepath.Clean(path), true
+		}
+	}
+
+	return "", false
+}
+
+// urlSchemePattern matches requirements given as URLs, such as
+// "https://example.com/flask-3.0.0.tar.gz" or "git+https://...".
+var urlSchemePattern = regexp.MustCompile(`^(?:[a-z]+\+)?(?:https?|file|ftp|ssh|git):`)
+
+// urlMarkerPattern matches the separator of the marker of a requirement given
+// as a URL, which must be surrounded by whitespace on at least one side.
+var urlMarkerPattern = regexp.MustCompile(`\s;|;\s`)
+
+// Marker returns the environment marker of the requirement, if any. Like pip,
+// the marker of a requirement given as a URL, or as a direct reference to a
+// URL, must be separated from it by "; " or " ;", so that URLs containing
+// ';' are not split.
+func (l RequirementLine) Marker() (pep508.Marker, bool, error) {
+	var text string
+	if l.isURL() {
+		location := urlMarkerPattern.FindStringIndex(l.Requirement)
+		if location == nil {
+			return pep508.Marker{}, false, nil
+		}
+		text = l.Requirement[location[1]:]
+	} else {
+		var found bool
+		_, text, found = strings.Cut(l.Requirement, ";")
+		if !found {
+			return pep508.Marker{}, false, nil
+		}
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return pep508.Marker{}, false, nil
+	}
+
+	marker, err := pep508.ParseMarker(text)
+	if err != nil {
+		return pep508.Marker{}, false, fmt.Errorf("%s: %w", l.Location(), err)
+	}
+
+	return marker, true, nil
+}
+
+// isURL reports whether the requirement is given as a URL, or as a direct
+// reference to one.
+func (l RequirementLine) isURL() bool {
+	if urlSchemePattern.MatchString(l.Requirement) {
+		return true
+	}
+
+	match := directReferencePattern.FindStringSubmatch(l.Requirement)
+	return match != nil && (urlSchemePattern.MatchString(match[1]) || strings.Contains(match[1], "://"))
+}
+
+// WriteRequirements writes the given lines to a requirements file at path.
+func WriteRequirements(path string, lines []RequirementLine) error {
+	var content strings.Builder
+	for _, line := range lines {
+		content.WriteString(line.String())
+		content.WriteString("\n")
+	}
+
+	return os.WriteFile(path, []byte(content.String()), 0644)
+}
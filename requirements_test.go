@@ -0,0 +1,226 @@
+package pipinstall_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRequirements(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+	)
+
+	it.Before(func() {
+		workingDir = t.TempDir()
+	})
+
+	context("ParseRequirements", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(workingDir, "requirements", "wheels"), os.ModePerm)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "requirements.txt"), []byte(`# a comment
+--index-url https://pypi.example.com/simple
+
+Flask==3.0.0  # trailing comment
+requests >= 2.0 ; python_version >= "3.8" \
+    --hash=sha256:abc
+-e .
+--editable=./libs/shared
+-r requirements/base.txt
+-c constraints.txt
+token==${SOME_TOKEN}
+`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "requirements", "base.txt"), []byte(`gunicorn
+--find-links wheels
+--find-links=missing
+`), 0600)).To(Succeed())
+
+			t.Setenv("SOME_TOKEN", "1.2.3")
+		})
+
+		it("returns the logical lines with includes expanded", func() {
+			lines, err := pipinstall.ParseRequirements(workingDir, []string{"requirements.txt"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(lines).To(Equal([]pipinstall.RequirementLine{
+				{File: "requirements.txt", Line: 2, Options: []string{"--index-url", "https://pypi.example.com/simple"}},
+				{File: "requirements.txt", Line: 4, Requirement: "Flask==3.0.0"},
+				{File: "requirements.txt", Line: 5, Requirement: `requests >= 2.0 ; python_version >= "3.8"`, Options: []string{"--hash=sha256:abc"}},
+				{File: "requirements.txt", Line: 7, Requirement: ".", Editable: true},
+				{File: "requirements.txt", Line: 8, Requirement: "./libs/shared", Editable: true},
+				{File: filepath.Join("requirements", "base.txt"), Line: 1, Requirement: "gunicorn"},
+				{File: filepath.Join("requirements", "base.txt"), Line: 2, Options: []string{"--find-links", filepath.Join(workingDir, "requirements", "wheels")}},
+				{File: filepath.Join("requirements", "base.txt"), Line: 3, Options: []string{"--find-links=missing"}},
+				{File: "requirements.txt", Line: 10, Options: []string{"-c", filepath.Join(workingDir, "constraints.txt")}},
+				{File: "requirements.txt", Line: 11, Requirement: "token==1.2.3"},
+			}))
+		})
+
+		context("when a requirements file includes a remote one", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "requirements", "base.txt"), []byte("-r https://example.com/requirements.txt\n-c https://example.com/constraints.txt\n"), 0600)).To(Succeed())
+			})
+
+			it("leaves it to pip", func() {
+				lines, err := pipinstall.ParseRequirements(workingDir, []string{filepath.Join("requirements", "base.txt")})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lines).To(Equal([]pipinstall.RequirementLine{
+					{File: filepath.Join("requirements", "base.txt"), Line: 1, Options: []string{"-r", "https://example.com/requirements.txt"}},
+					{File: filepath.Join("requirements", "base.txt"), Line: 2, Options: []string{"-c", "https://example.com/constraints.txt"}},
+				}))
+			})
+		})
+
+		it("skips files that do not exist", func() {
+			lines, err := pipinstall.ParseRequirements(workingDir, []string{"missing.txt", filepath.Join("requirements", "base.txt")})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lines).To(HaveLen(3))
+		})
+
+		context("failure cases", func() {
+			context("when a requirements file includes itself", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "requirements", "base.txt"), []byte("-r ../requirements.txt\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := pipinstall.ParseRequirements(workingDir, []string{"requirements.txt"})
+					Expect(err).To(MatchError(ContainSubstring("includes itself")))
+				})
+			})
+
+			context("when an included file does not exist", func() {
+				it.Before(func() {
+					Expect(os.Remove(filepath.Join(workingDir, "requirements", "base.txt"))).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := pipinstall.ParseRequirements(workingDir, []string{"requirements.txt"})
+					Expect(err).To(MatchError(ContainSubstring("failed to read requirements file")))
+				})
+			})
+
+			context("when an editable option has no value", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "requirements.txt"), []byte("flask\n-e\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := pipinstall.ParseRequirements(workingDir, []string{"requirements.txt"})
+					Expect(err).To(MatchError("requirements.txt:2: -e requires an argument"))
+				})
+			})
+		})
+	})
+
+	context("RequirementLine", func() {
+		context("LocalProject", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "pyproject.toml"), nil, 0600)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(workingDir, "libs", "shared"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "libs", "shared", "setup.py"), nil, 0600)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(workingDir, "dist"), os.ModePerm)).To(Succeed())
+			})
+
+			it("returns the project directory of local requirements", func() {
+				for requirement, expected := range map[string]string{
+					".":                   workingDir,
+					"./libs/shared":       filepath.Join(workingDir, "libs", "shared"),
+					"libs/shared[server]": filepath.Join(workingDir, "libs", "shared"),
+					"./libs/shared ; python_version >= '3.8'":        filepath.Join(workingDir, "libs", "shared"),
+					"shared @ file://" + workingDir + "/libs/shared": filepath.Join(workingDir, "libs", "shared"),
+					"file:" + workingDir:                             workingDir,
+				} {
+					path, ok := pipinstall.RequirementLine{Requirement: requirement}.LocalProject(workingDir)
+					Expect(ok).To(BeTrue(), requirement)
+					Expect(path).To(Equal(expected), requirement)
+				}
+			})
+
+			it("ignores other requirements", func() {
+				for _, requirement := range []string{
+					"flask",
+					"flask>=3.0",
+					"./dist",
+					"./dist/flask-3.0.0-py3-none-any.whl",
+					"https://example.com/flask-3.0.0.tar.gz",
+					"flask @ https://example.com/flask-3.0.0.tar.gz",
+					"git+https://example.com/flask.git",
+				} {
+					_, ok := pipinstall.RequirementLine{Requirement: requirement}.LocalProject(workingDir)
+					Expect(ok).To(BeFalse(), requirement)
+				}
+			})
+		})
+
+		context("Marker", func() {
+			it("returns the environment marker of the requirement", func() {
+				for requirement, expected := range map[string]string{
+					`flask ; python_version >= "3.8"`:                               `python_version >= "3.8"`,
+					`flask>=3;sys_platform=="linux"`:                                `sys_platform=="linux"`,
+					`flask @ https://example.com/flask.tar.gz ; os_name == "posix"`: `os_name == "posix"`,
+					`https://example.com/flask.tar.gz; os_name == "posix"`:          `os_name == "posix"`,
+					`foo @ https://host/a;b.whl ; python_version > "3"`:             `python_version > "3"`,
+					`foo @ https://host/a;b.whl ;python_version > "3"`:              `python_version > "3"`,
+				} {
+					marker, ok, err := pipinstall.RequirementLine{Requirement: requirement}.Marker()
+					Expect(err).NotTo(HaveOccurred(), requirement)
+					Expect(ok).To(BeTrue(), requirement)
+					Expect(marker.String()).To(Equal(expected), requirement)
+				}
+			})
+
+			it("does not split URLs on a bare ';'", func() {
+				for _, requirement := range []string{"flask", "https://example.com/flask;v=1.tar.gz", "foo @ https://host/a;b.whl", "flask ;"} {
+					_, ok, err := pipinstall.RequirementLine{Requirement: requirement}.Marker()
+					Expect(err).NotTo(HaveOccurred(), requirement)
+					Expect(ok).To(BeFalse(), requirement)
+				}
+			})
+
+			it("returns an error for invalid markers", func() {
+				_, _, err := pipinstall.RequirementLine{File: "requirements.txt", Line: 3, Requirement: "flask ; python_version"}.Marker()
+				Expect(err).To(MatchError(ContainSubstring("requirements.txt:3: invalid marker 'python_version'")))
+			})
+		})
+
+		context("String", func() {
+			it("returns a line that pip can read", func() {
+				Expect(pipinstall.RequirementLine{Requirement: "flask==3.0.0", Options: []string{"--hash=sha256:abc"}}.String()).To(Equal("flask==3.0.0 --hash=sha256:abc"))
+				Expect(pipinstall.RequirementLine{Requirement: ".", Editable: true}.String()).To(Equal("--editable ."))
+				Expect(pipinstall.RequirementLine{Options: []string{"--index-url", "https://pypi.example.com/simple"}}.String()).To(Equal("--index-url https://pypi.example.com/simple"))
+			})
+		})
+
+		context("Location", func() {
+			it("returns the file and line", func() {
+				Expect(pipinstall.RequirementLine{File: "requirements.txt", Line: 3}.Location()).To(Equal("requirements.txt:3"))
+				Expect(pipinstall.RequirementLine{File: "pyproject.toml"}.Location()).To(Equal("pyproject.toml"))
+			})
+		})
+	})
+
+	context("WriteRequirements", func() {
+		it("writes the lines to a file", func() {
+			path := filepath.Join(workingDir, "requirements.txt")
+			Expect(pipinstall.WriteRequirements(path, []pipinstall.RequirementLine{
+				{Options: []string{"--index-url", "https://pypi.example.com/simple"}},
+				{Requirement: "flask==3.0.0"},
+			})).To(Succeed())
+
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("--index-url https://pypi.example.com/simple\nflask==3.0.0\n"))
+		})
+	})
+}
@@ -0,0 +1,74 @@
+package pipinstall
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// maxRetryBackoff bounds the delay between two attempts of an install.
+const maxRetryBackoff = 30 * time.Second
+
+// transientPattern matches the messages with which pip reports failures to
+// reach the index that may not happen again: dropped connections, read
+// timeouts and 5xx responses.
+var transientPattern = regexp.MustCompile(`(?i)connection reset by peer|ConnectionResetError|RemoteDisconnected|IncompleteRead|Connection aborted|Read timed out|ReadTimeoutError|HTTP error 5\d\d|5\d\d Server Error[^\n]*|too many 5\d\d error responses`)
+
+// installRetries returns how many times a pip install that failed for a
+// transient reason is retried, as given in BP_PIP_RETRIES.
+func installRetries() (int, error) {
+	value, exists := os.LookupEnv("BP_PIP_RETRIES")
+	if !exists || value == "" {
+		return 0, nil
+	}
+
+	retries, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse BP_PIP_RETRIES value '%s': %w", value, err)
+	}
+
+	return int(retries), nil
+}
+
+// transientFailure returns the reason of a failure that pip reported in its
+// output, and reports whether the failure is transient. Other failures, such
+// as a requirement that cannot be resolved or a build that fails, happen
+// again on every attempt.
+func transientFailure(output string) (string, bool) {
+	reason := transientPattern.FindString(output)
+	return reason, reason != ""
+}
+
+// retry runs the attempt until it succeeds, up to retries more times when it
+// fails for a transient reason, waiting twice as long before each retry as
+// before the previous one. The attempt returns the output pip reported its
+// failure in, which is returned along with the error of the last attempt.
+// Attempts share the pip cache, so that what was downloaded before a failure
+// is not downloaded again.
+func (p PipInstallProcess) retry(ctx context.Context, retries int, attempt func() (string, error)) (string, error) {
+	delay := p.backoff
+	for retry := 1; ; retry++ {
+		output, err := attempt()
+		if err == nil || retry > retries || ctx.Err() != nil {
+			return output, err
+		}
+
+		reason, transient := transientFailure(output)
+		if !transient {
+			return output, err
+		}
+
+		p.logger.Subprocess("Retrying in %s (retry %d of %d): %s", delay, retry, retries, reason)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return output, err
+		}
+
+		delay = min(2*delay, maxRetryBackoff)
+	}
+}
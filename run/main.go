@@ -8,6 +8,7 @@ import (
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 	pipinstall "github.com/paketo-buildpacks/pip-install"
 )
 
@@ -23,8 +24,18 @@ func main() {
 	packit.Run(
 		pipinstall.Detect(),
 		pipinstall.Build(
-			pipinstall.NewPipInstallProcess(pexec.NewExecutable("pip"), logger),
+			pipinstall.NewPipInstallProcess(
+				pipinstall.NewProcessGroupExecutable("pip"),
+				pipinstall.NewGitResolver(pipinstall.NewProcessGroupExecutable("git"), logger),
+				pipinstall.NewEnvironmentProcess(pexec.NewExecutable("python")),
+				pipinstall.NewNativeToolchainProcess(pexec.NewExecutable("python")),
+				logger,
+			),
+			pipinstall.NewPythonVenvProcess(pexec.NewExecutable("python")),
 			pipinstall.NewSiteProcess(pexec.NewExecutable("python")),
+			pipinstall.NewCompileProcess(pexec.NewExecutable("python"), logger),
+			pipinstall.NewPipCacheProcess(),
+			servicebindings.NewResolver(),
 			Generator{},
 			chronos.DefaultClock,
 			logger,
@@ -0,0 +1,153 @@
+package pipinstall
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+// requestedProperty is the CycloneDX property, and the comment of the SPDX
+// annotation, that marks the packages requested by other buildpacks through
+// the build plan.
+const requestedProperty = "paketo:pip-install:requested-by-buildpack"
+
+// requestedSBOM wraps the SBOM formats of the packages layer, so that the
+// components of the packages requested by other buildpacks are marked in
+// the CycloneDX and SPDX documents. The syft JSON document, which has no
+// place for such a mark, is left as is.
+type requestedSBOM struct {
+	formatter packit.SBOMFormatter
+	names     map[string]bool
+}
+
+// newRequestedSBOM returns the formatter, with the packages, which are the
+// requirement specifiers returned by planPackages, marked in its documents.
+func newRequestedSBOM(formatter packit.SBOMFormatter, packages []string) requestedSBOM {
+	names := map[string]bool{}
+	for _, specifier := range packages {
+		if requirement, err := pep508.ParseRequirement(specifier); err == nil {
+			names[NormalizeName(requirement.Name)] = true
+		}
+	}
+
+	return requestedSBOM{formatter: formatter, names: names}
+}
+
+func (s requestedSBOM) Formats() []packit.SBOMFormat {
+	var formats []packit.SBOMFormat
+	for _, format := range s.formatter.Formats() {
+		var mark func(map[string]interface{}, map[string]bool)
+		switch {
+		case strings.HasSuffix(format.Extension, "cdx.json"):
+			mark = markCycloneDX
+		case strings.HasSuffix(format.Extension, "spdx.json"):
+			mark = markSPDX
+		}
+
+		if mark != nil {
+			format.Content = &markedReader{content: format.Content, mark: mark, names: s.names}
+		}
+		formats = append(formats, format)
+	}
+
+	return formats
+}
+
+// markedReader reads the JSON document of the content with the requested
+// packages marked, once it is first read.
+type markedReader struct {
+	content io.Reader
+	mark    func(map[string]interface{}, map[string]bool)
+	names   map[string]bool
+
+	reader *bytes.Reader
+	err    error
+}
+
+func (r *markedReader) Read(p []byte) (int, error) {
+	if r.reader == nil && r.err == nil {
+		r.reader, r.err = r.marked()
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	return r.reader.Read(p)
+}
+
+func (r *markedReader) marked() (*bytes.Reader, error) {
+	decoder := json.NewDecoder(r.content)
+	decoder.UseNumber()
+
+	var document map[string]interface{}
+	err := decoder.Decode(&document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark the requested packages in the SBOM: %w", err)
+	}
+
+	r.mark(document, r.names)
+
+	content, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark the requested packages in the SBOM: %w", err)
+	}
+
+	return bytes.NewReader(content), nil
+}
+
+// markCycloneDX adds the requestedProperty to the properties of the
+// components of the requested packages.
+func markCycloneDX(document map[string]interface{}, names map[string]bool) {
+	components, _ := document["components"].([]interface{})
+	for _, item := range components {
+		component, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _ := component["name"].(string)
+		if !names[NormalizeName(name)] {
+			continue
+		}
+
+		properties, _ := component["properties"].([]interface{})
+		component["properties"] = append(properties, map[string]interface{}{
+			"name":  requestedProperty,
+			"value": "true",
+		})
+	}
+}
+
+// markSPDX adds an annotation with the requestedProperty as its comment to
+// the packages of the requested packages. The annotation is dated with the
+// creation of the document, so that the document stays reproducible.
+func markSPDX(document map[string]interface{}, names map[string]bool) {
+	creationInfo, _ := document["creationInfo"].(map[string]interface{})
+	created, _ := creationInfo["created"].(string)
+
+	packages, _ := document["packages"].([]interface{})
+	for _, item := range packages {
+		pkg, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _ := pkg["name"].(string)
+		if !names[NormalizeName(name)] {
+			continue
+		}
+
+		annotations, _ := pkg["annotations"].([]interface{})
+		pkg["annotations"] = append(annotations, map[string]interface{}{
+			"annotationType": "OTHER",
+			"annotator":      "Tool: pip-install",
+			"annotationDate": created,
+			"comment":        requestedProperty,
+		})
+	}
+}
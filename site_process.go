@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/paketo-buildpacks/packit/v2/pexec"
@@ -22,15 +23,41 @@ func NewSiteProcess(executable Executable) SiteProcess {
 }
 
 // Execute runs a python command to locate the site packages within the pip targetLayerPath.
+//
+// With `BP_PIP_INSTALL_LAYOUT` set to "venv", the site packages are those of
+// the virtual environment at the layerPath, which are located by the
+// interpreter of the virtual environment. With it set to "target", they are
+// at a fixed path of the layerPath, which does not depend on the interpreter.
 func (p SiteProcess) Execute(layerPath string) (string, error) {
+	layout, err := installLayout()
+	if err != nil {
+		return "", err
+	}
+
+	if layout == "target" {
+		return targetSitePackages(layerPath), nil
+	}
+
+	execution := pexec.Execution{
+		Args: []string{"-m", "site", "--user-site"},
+		Env:  append(os.Environ(), fmt.Sprintf("PYTHONUSERBASE=%s", layerPath)),
+	}
+
+	if layout == "venv" {
+		execution = pexec.Execution{
+			Args: []string{"-c", "import sysconfig; print(sysconfig.get_path('purelib'))"},
+			Env: append(os.Environ(),
+				fmt.Sprintf("PATH=%s%c%s", filepath.Join(layerPath, "bin"), os.PathListSeparator, os.Getenv("PATH")),
+				fmt.Sprintf("VIRTUAL_ENV=%s", layerPath),
+			),
+		}
+	}
+
 	buffer := bytes.NewBuffer(nil)
+	execution.Stdout = buffer
+	execution.Stderr = buffer
 
-	err := p.executable.Execute(pexec.Execution{
-		Args:   []string{"-m", "site", "--user-site"},
-		Env:    append(os.Environ(), fmt.Sprintf("PYTHONUSERBASE=%s", layerPath)),
-		Stdout: buffer,
-		Stderr: buffer,
-	})
+	err = p.executable.Execute(execution)
 	if err != nil {
 		return "", fmt.Errorf("failed to locate site packages:\n%s\nerror: %w", buffer.String(), err)
 	}
@@ -53,6 +53,39 @@ func testSiteProcess(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when BP_PIP_INSTALL_LAYOUT is venv", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_INSTALL_LAYOUT", "venv")
+			})
+
+			it("asks the interpreter of the virtual environment", func() {
+				sitePackagesPath, err := process.Execute(layerPath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executable.ExecuteCall.Receives.Execution.Env).To(Equal(append(os.Environ(),
+					fmt.Sprintf("PATH=%s:%s", filepath.Join(layerPath, "bin"), os.Getenv("PATH")),
+					fmt.Sprintf("VIRTUAL_ENV=%s", layerPath),
+				)))
+				Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"-c", "import sysconfig; print(sysconfig.get_path('purelib'))"}))
+
+				Expect(sitePackagesPath).To(Equal(filepath.Join(layerPath, "pip", "lib", "python", "site-packages")))
+			})
+		})
+
+		context("when BP_PIP_INSTALL_LAYOUT is target", func() {
+			it.Before(func() {
+				t.Setenv("BP_PIP_INSTALL_LAYOUT", "target")
+			})
+
+			it("returns the fixed site-packages path of the layer", func() {
+				sitePackagesPath, err := process.Execute(layerPath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+				Expect(sitePackagesPath).To(Equal(filepath.Join(layerPath, "site-packages")))
+			})
+		})
+
 		context("failure cases", func() {
 			context("site package lookup fails", func() {
 				it.Before(func() {
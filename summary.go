@@ -0,0 +1,90 @@
+package pipinstall
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// buildSummary gathers the figures of a build that are logged in a table at
+// its end.
+type buildSummary struct {
+	install      InstallResult
+	packagesSize int64
+	cacheSize    int64
+	compiled     bool
+	compile      time.Duration
+	sbom         time.Duration
+}
+
+// log writes the summary to the logger as a table of labelled rows. The
+// compile phase is marked as not run when pip compiled the bytecode during
+// the install, or when compilation is off.
+func (s buildSummary) log(logger scribe.Emitter) {
+	compile := "-"
+	if s.compiled {
+		compile = formatDuration(s.compile)
+	}
+
+	rows := [][2]string{
+		{"Distributions", fmt.Sprintf("%d installed (%d direct, %d transitive)", s.install.Direct+s.install.Transitive, s.install.Direct, s.install.Transitive)},
+		{"Wheels", fmt.Sprintf("%d", s.install.Wheels)},
+		{"Source builds", fmt.Sprintf("%d", s.install.SourceBuilds)},
+		{"Cache hits", fmt.Sprintf("%d", s.install.CacheHits)},
+		{"Packages layer", formatSize(s.packagesSize)},
+		{"Cache layer", formatSize(s.cacheSize)},
+		{"Resolve/download", formatDuration(s.install.ResolveDuration)},
+		{"Build wheels", formatDuration(s.install.BuildDuration)},
+		{"Install", formatDuration(s.install.InstallDuration)},
+		{"Compile", compile},
+		{"SBOM", formatDuration(s.sbom)},
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row[0]))
+	}
+
+	logger.Process("Build summary")
+	for _, row := range rows {
+		logger.Subprocess("%-*s  %s", width, row[0], row[1])
+	}
+	logger.Break()
+}
+
+func formatDuration(duration time.Duration) string {
+	return duration.Round(time.Millisecond).String()
+}
+
+// dirSize returns the total size of the regular files under path, or zero
+// when it does not exist.
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	return size, nil
+}
@@ -0,0 +1,73 @@
+package pipinstall
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// installTimeout returns the duration the install may take, as given in
+// BP_PIP_TIMEOUT, such as "10m". Zero means that it is not bounded.
+func installTimeout() (time.Duration, error) {
+	value, exists := os.LookupEnv("BP_PIP_TIMEOUT")
+	if !exists || value == "" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err == nil && timeout < 0 {
+		err = fmt.Errorf("negative duration")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse BP_PIP_TIMEOUT value '%s': %w", value, err)
+	}
+
+	return timeout, nil
+}
+
+// installContext returns the context the install runs in, which is done
+// once the timeout, if any, has passed or the buildpack receives SIGTERM or
+// SIGINT. Its cause describes which of them happened.
+func installContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			cancel(fmt.Errorf("was cancelled by signal '%s': %w", sig, context.Canceled))
+		case <-ctx.Done():
+		}
+	}()
+
+	stop := func() {
+		signal.Stop(signals)
+		cancel(context.Canceled)
+	}
+
+	if timeout == 0 {
+		return ctx, stop
+	}
+
+	ctx, cancelTimeout := context.WithTimeoutCause(ctx, timeout, fmt.Errorf("timed out after %s (BP_PIP_TIMEOUT=%s): %w", timeout, os.Getenv("BP_PIP_TIMEOUT"), context.DeadlineExceeded))
+
+	return ctx, func() {
+		cancelTimeout()
+		stop()
+	}
+}
+
+// interrupted returns err, unless the ctx is done, in which case it returns
+// an error naming the phase of the install that was interrupted and why.
+func interrupted(ctx context.Context, phase string, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+
+	return fmt.Errorf("%s %w", phase, context.Cause(ctx))
+}
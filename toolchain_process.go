@@ -0,0 +1,111 @@
+package pipinstall
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// Toolchain describes the native toolchain available to build distributions
+// from source.
+type Toolchain struct {
+	// Compiler is the path of the C compiler, or empty when none was found.
+	Compiler string
+
+	// PythonHeaders is the path of the Python.h header of the interpreter, or
+	// empty when it was not found.
+	PythonHeaders string
+
+	// IncludeDirs are the directories searched for the headers of system
+	// libraries.
+	IncludeDirs []string
+}
+
+// HasCommand reports whether the command is found on the PATH.
+func (t Toolchain) HasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// HasHeader reports whether the header, such as "ldap.h" or "sasl/sasl.h",
+// is found in one of the IncludeDirs.
+func (t Toolchain) HasHeader(name string) bool {
+	for _, dir := range t.IncludeDirs {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// compilers are the C compilers looked for when `CC` is not set.
+var compilers = []string{"cc", "gcc", "clang"}
+
+// defaultIncludeDirs are the directories the C compiler searches for headers
+// by default.
+var defaultIncludeDirs = []string{"/usr/local/include", "/usr/include"}
+
+// NativeToolchainProcess implements the ToolchainProcess interface.
+type NativeToolchainProcess struct {
+	executable Executable
+}
+
+// NewNativeToolchainProcess creates an instance of the NativeToolchainProcess
+// given an Executable that runs `python`.
+func NewNativeToolchainProcess(executable Executable) NativeToolchainProcess {
+	return NativeToolchainProcess{
+		executable: executable,
+	}
+}
+
+// Execute looks for a C compiler, either given in `CC` or one of cc, gcc or
+// clang on the PATH, and runs a python command to locate the headers of the
+// interpreter. Headers of system libraries are looked for in the directories
+// given in `CPATH` and `C_INCLUDE_PATH`, and in /usr/local/include and
+// /usr/include.
+func (p NativeToolchainProcess) Execute() (Toolchain, error) {
+	var toolchain Toolchain
+
+	candidates := compilers
+	if cc := strings.Fields(os.Getenv("CC")); len(cc) > 0 {
+		candidates = cc[:1]
+	}
+
+	for _, compiler := range candidates {
+		if path, err := exec.LookPath(compiler); err == nil {
+			toolchain.Compiler = path
+			break
+		}
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err := p.executable.Execute(pexec.Execution{
+		Args:   []string{"-c", "import sysconfig; print(sysconfig.get_path('include'))"},
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+	if err != nil {
+		return Toolchain{}, fmt.Errorf("failed to locate the Python headers:\n%s\nerror: %w", buffer.String(), err)
+	}
+
+	headers := filepath.Join(strings.TrimSpace(buffer.String()), "Python.h")
+	if info, err := os.Stat(headers); err == nil && !info.IsDir() {
+		toolchain.PythonHeaders = headers
+	}
+
+	for _, variable := range []string{"CPATH", "C_INCLUDE_PATH"} {
+		for _, dir := range filepath.SplitList(os.Getenv(variable)) {
+			if dir != "" {
+				toolchain.IncludeDirs = append(toolchain.IncludeDirs, dir)
+			}
+		}
+	}
+	toolchain.IncludeDirs = append(toolchain.IncludeDirs, defaultIncludeDirs...)
+
+	return toolchain, nil
+}
@@ -0,0 +1,130 @@
+package pipinstall_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+	"github.com/paketo-buildpacks/pip-install/fakes"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testToolchainProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		binDir     string
+		includeDir string
+		executable *fakes.Executable
+
+		process pipinstall.NativeToolchainProcess
+	)
+
+	it.Before(func() {
+		binDir = t.TempDir()
+		includeDir = t.TempDir()
+
+		t.Setenv("PATH", binDir)
+		t.Setenv("CPATH", "")
+		t.Setenv("C_INCLUDE_PATH", "")
+		t.Setenv("CC", "")
+
+		Expect(os.WriteFile(filepath.Join(binDir, "gcc"), nil, 0700)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(includeDir, "Python.h"), nil, 0600)).To(Succeed())
+
+		executable = &fakes.Executable{}
+		executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+			_, err := fmt.Fprintln(execution.Stdout, includeDir)
+			Expect(err).NotTo(HaveOccurred())
+			return nil
+		}
+
+		process = pipinstall.NewNativeToolchainProcess(executable)
+	})
+
+	context("Execute", func() {
+		it("returns the compiler and the Python headers", func() {
+			toolchain, err := process.Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"-c", "import sysconfig; print(sysconfig.get_path('include'))"}))
+			Expect(toolchain.Compiler).To(Equal(filepath.Join(binDir, "gcc")))
+			Expect(toolchain.PythonHeaders).To(Equal(filepath.Join(includeDir, "Python.h")))
+			Expect(toolchain.IncludeDirs).To(Equal([]string{"/usr/local/include", "/usr/include"}))
+		})
+
+		context("when CC is set", func() {
+			it.Before(func() {
+				t.Setenv("CC", "clang -std=c11")
+			})
+
+			it("only looks for that compiler", func() {
+				toolchain, err := process.Execute()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(toolchain.Compiler).To(BeEmpty())
+
+				Expect(os.WriteFile(filepath.Join(binDir, "clang"), nil, 0700)).To(Succeed())
+
+				toolchain, err = process.Execute()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(toolchain.Compiler).To(Equal(filepath.Join(binDir, "clang")))
+			})
+		})
+
+		context("when the Python headers are not installed", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(includeDir, "Python.h"))).To(Succeed())
+			})
+
+			it("reports them as missing", func() {
+				toolchain, err := process.Execute()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(toolchain.PythonHeaders).To(BeEmpty())
+			})
+		})
+
+		context("when CPATH and C_INCLUDE_PATH are set", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(includeDir, "sasl"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(includeDir, "sasl", "sasl.h"), nil, 0600)).To(Succeed())
+
+				t.Setenv("CPATH", includeDir)
+				t.Setenv("C_INCLUDE_PATH", "/some/include:/other/include")
+			})
+
+			it("searches them for headers and finds commands on the PATH", func() {
+				toolchain, err := process.Execute()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(toolchain.IncludeDirs).To(Equal([]string{includeDir, "/some/include", "/other/include", "/usr/local/include", "/usr/include"}))
+				Expect(toolchain.HasHeader("sasl/sasl.h")).To(BeTrue())
+				Expect(toolchain.HasHeader("sasl")).To(BeFalse())
+				Expect(toolchain.HasHeader("some-missing-header.h")).To(BeFalse())
+				Expect(toolchain.HasCommand("gcc")).To(BeTrue())
+				Expect(toolchain.HasCommand("pg_config")).To(BeFalse())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the python command fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stderr, "some-output")
+						return errors.New("some-error")
+					}
+				})
+
+				it("returns an error", func() {
+					_, err := process.Execute()
+					Expect(err).To(MatchError("failed to locate the Python headers:\nsome-output\n\nerror: some-error"))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,229 @@
+package pipinstall
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// VCSRequirement is a requirement that installs a project from a git
+// repository, such as "git+https://github.com/org/repo.git@v1.0#egg=repo" or
+// "repo @ git+https://github.com/org/repo.git@v1.0".
+type VCSRequirement struct {
+	// Name is the project name given in a direct reference, if any.
+	Name string
+
+	// Extras holds the extras of a direct reference, including brackets.
+	Extras string
+
+	// Repository is the URL of the repository, without the "git+" prefix,
+	// revision or fragment.
+	Repository string
+
+	// Ref is the requested revision: a branch, tag or commit. It is empty
+	// when the default branch was requested.
+	Ref string
+
+	// Fragment holds the URL fragment, such as "egg=repo&subdirectory=lib".
+	Fragment string
+
+	// Marker is the environment marker of the requirement, if any.
+	Marker string
+}
+
+var (
+	vcsReferencePattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*@\s*(git\+\S+)$`)
+	fullCommitPattern   = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+)
+
+// ParseVCSRequirement parses a requirement that refers to a git repository.
+// Requirements for other version control systems are not supported.
+func ParseVCSRequirement(requirement string) (VCSRequirement, bool) {
+	var result VCSRequirement
+
+	location, marker, _ := strings.Cut(requirement, ";")
+	location = strings.TrimSpace(location)
+	result.Marker = strings.TrimSpace(marker)
+
+	if match := vcsReferencePattern.FindStringSubmatch(location); match != nil {
+		result.Name = match[1]
+		result.Extras = match[2]
+		location = match[3]
+	}
+
+	if !strings.HasPrefix(location, "git+") {
+		return VCSRequirement{}, false
+	}
+
+	location, result.Fragment, _ = strings.Cut(strings.TrimPrefix(location, "git+"), "#")
+
+	parsed, err := url.Parse(location)
+	if err != nil || parsed.Scheme == "" {
+		return VCSRequirement{}, false
+	}
+
+	// Like pip, the revision follows the last '@' in the path, so that
+	// credentials such as "git@github.com" are not mistaken for it.
+	if index := strings.LastIndex(parsed.Path, "@"); index >= 0 {
+		result.Ref = parsed.Path[index+1:]
+		parsed.Path = parsed.Path[:index]
+		parsed.RawPath = ""
+	}
+
+	result.Repository = parsed.String()
+
+	return result, true
+}
+
+// Pinned reports whether the requested revision is a full commit SHA.
+func (r VCSRequirement) Pinned() bool {
+	return fullCommitPattern.MatchString(r.Ref)
+}
+
+// String returns the requirement in a form that pip can install.
+func (r VCSRequirement) String() string {
+	location := "git+" + r.Repository
+	if r.Ref != "" {
+		location = fmt.Sprintf("%s@%s", location, r.Ref)
+	}
+	if r.Fragment != "" {
+		location = fmt.Sprintf("%s#%s", location, r.Fragment)
+	}
+
+	if r.Name != "" {
+		location = fmt.Sprintf("%s%s @ %s", r.Name, r.Extras, location)
+	}
+
+	if r.Marker != "" {
+		location = fmt.Sprintf("%s ; %s", location, r.Marker)
+	}
+
+	return location
+}
+
+// Redacted returns the repository URL with any credentials removed, so that
+// it can be logged and recorded. Over HTTP(S) the whole user information is
+// removed, as access tokens are commonly given as the username.
+func (r VCSRequirement) Redacted() string {
+	return redactCredentials(r.Repository)
+}
+
+// vcsRequirement is a VCS requirement that has been resolved to a commit in a
+// cached clone of its repository.
+type vcsRequirement struct {
+	line     RequirementLine
+	original VCSRequirement
+	clone    string
+	commit   string
+}
+
+// resolveVCSRequirements resolves every git requirement in lines to a commit
+// in a clone cached under cacheDir and returns the lines with those
+// requirements replaced by references to the commit in the clone, so that
+// pip installs them without cloning the repository again.
+//
+// Depending on BP_PIP_VCS_REF_POLICY, a requirement whose revision is not a
+// full commit SHA either fails the build ("fail") or logs a warning ("warn",
+// the default).
+func (p PipInstallProcess) resolveVCSRequirements(ctx context.Context, lines []RequirementLine, cacheDir string) ([]RequirementLine, []vcsRequirement, error) {
+	policy := "warn"
+	if value, ok := os.LookupEnv("BP_PIP_VCS_REF_POLICY"); ok {
+		policy = value
+	}
+
+	if policy != "warn" && policy != "fail" {
+		return nil, nil, fmt.Errorf("invalid BP_PIP_VCS_REF_POLICY value '%s': must be one of 'warn' or 'fail'", policy)
+	}
+
+	var (
+		rewritten []RequirementLine
+		resolved  []vcsRequirement
+		unpinned  []string
+	)
+
+	for _, line := range lines {
+		requirement, ok := ParseVCSRequirement(line.Requirement)
+		if !ok {
+			rewritten = append(rewritten, line)
+			continue
+		}
+
+		ref := requirement.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+
+		if !requirement.Pinned() {
+			message := fmt.Sprintf("%s: '%s' requests revision '%s', which is not a full commit SHA", line.Location(), requirement.Redacted(), ref)
+			if policy == "fail" {
+				unpinned = append(unpinned, message)
+				continue
+			}
+			p.logger.Subprocess("Warning: %s", message)
+		}
+
+		clone, commit, err := p.vcsResolver.Resolve(ctx, requirement.Repository, requirement.Ref, cacheDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve '%s' (%s): %w", requirement.Redacted(), line.Location(), err)
+		}
+
+		p.logger.Subprocess("Resolved '%s@%s' (%s) to commit %s", requirement.Redacted(), ref, line.Location(), commit)
+
+		local := requirement
+		local.Repository = (&url.URL{Scheme: "file", Path: clone}).String()
+		local.Ref = commit
+
+		rewritten = append(rewritten, RequirementLine{
+			File:        line.File,
+			Line:        line.Line,
+			Requirement: local.String(),
+			Options:     line.Options,
+		})
+		resolved = append(resolved, vcsRequirement{
+			line:     line,
+			original: requirement,
+			clone:    clone,
+			commit:   commit,
+		})
+	}
+
+	if len(unpinned) > 0 {
+		return nil, nil, fmt.Errorf("VCS requirements must be pinned to a full commit SHA (BP_PIP_VCS_REF_POLICY=fail):\n  %s", strings.Join(unpinned, "\n  "))
+	}
+
+	return rewritten, resolved, nil
+}
+
+// recordVCSRequirements rewrites the direct_url.json of the distributions
+// installed from cached clones, so that they record the original repository
+// URL and requested revision along with the resolved commit.
+func recordVCSRequirements(targetPath string, requirements []vcsRequirement) error {
+	clones := map[string]vcsRequirement{}
+	for _, requirement := range requirements {
+		clones[(&url.URL{Scheme: "file", Path: requirement.clone}).String()] = requirement
+	}
+
+	return rewriteDirectURLs(targetPath, func(directURL map[string]interface{}) bool {
+		location, _ := directURL["url"].(string)
+		requirement, ok := clones[location]
+		if !ok {
+			return false
+		}
+
+		vcsInfo := map[string]interface{}{
+			"vcs":       "git",
+			"commit_id": requirement.commit,
+		}
+		if requirement.original.Ref != "" {
+			vcsInfo["requested_revision"] = requirement.original.Ref
+		}
+
+		directURL["url"] = requirement.original.Redacted()
+		directURL["vcs_info"] = vcsInfo
+
+		return true
+	})
+}
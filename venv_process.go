@@ -0,0 +1,41 @@
+package pipinstall
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// PythonVenvProcess implements the VenvProcess interface.
+type PythonVenvProcess struct {
+	executable Executable
+}
+
+// NewPythonVenvProcess creates an instance of the PythonVenvProcess given an
+// Executable that runs `python`.
+func NewPythonVenvProcess(executable Executable) PythonVenvProcess {
+	return PythonVenvProcess{
+		executable: executable,
+	}
+}
+
+// Execute creates a virtual environment at path with the venv module of the
+// interpreter. An environment left at path by a previous build is cleared,
+// as it may have been created by another interpreter. pip is left out of the
+// environment, as it installs into it from outside through --python, so that
+// only the requested packages are installed in the layer.
+func (p PythonVenvProcess) Execute(path string) error {
+	buffer := bytes.NewBuffer(nil)
+
+	err := p.executable.Execute(pexec.Execution{
+		Args:   []string{"-m", "venv", "--clear", "--without-pip", path},
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create virtual environment:\n%s\nerror: %w", buffer.String(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package pipinstall_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	pipinstall "github.com/paketo-buildpacks/pip-install"
+	"github.com/paketo-buildpacks/pip-install/fakes"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testVenvProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		executable *fakes.Executable
+
+		process pipinstall.PythonVenvProcess
+	)
+
+	it.Before(func() {
+		executable = &fakes.Executable{}
+
+		process = pipinstall.NewPythonVenvProcess(executable)
+	})
+
+	context("Execute", func() {
+		it("creates a virtual environment without pip at the path", func() {
+			err := process.Execute("some-layer-path")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"-m", "venv", "--clear", "--without-pip", "some-layer-path"}))
+		})
+
+		context("failure cases", func() {
+			context("when the venv module fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stdout, "Error: Command '['python', '-m', 'ensurepip']' returned non-zero exit status 1.")
+						return errors.New("exit status 1")
+					}
+				})
+
+				it("returns an error", func() {
+					err := process.Execute("some-layer-path")
+					Expect(err).To(MatchError("failed to create virtual environment:\nError: Command '['python', '-m', 'ensurepip']' returned non-zero exit status 1.\n\nerror: exit status 1"))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,338 @@
+package pipinstall
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/pip-install/pep425"
+	"github.com/paketo-buildpacks/pip-install/pep508"
+)
+
+// sdistExtensions are the archive formats of source distributions that pip
+// builds into wheels.
+var sdistExtensions = []string{".tar.gz", ".tgz", ".tar.bz2", ".tbz", ".tar.xz", ".txz", ".tar", ".zip"}
+
+// implementationTags are the abbreviations of the Python implementations
+// used in wheel tags.
+var implementationTags = map[string]string{
+	"cpython":    "cp",
+	"pypy":       "pp",
+	"ironpython": "ip",
+	"jython":     "jy",
+}
+
+// pythonTag returns the wheel python tag of the interpreter described by the
+// environment, e.g. "cp312".
+func pythonTag(environment pep508.Environment) string {
+	implementation, ok := implementationTags[strings.ToLower(environment.ImplementationName)]
+	if !ok {
+		implementation = partitionUnsafePattern.ReplaceAllString(strings.ToLower(environment.ImplementationName), "_")
+	}
+
+	return implementation + strings.ReplaceAll(environment.PythonVersion, ".", "")
+}
+
+// localFindLinks returns the local directories among the find-links entries,
+// relative to workingDir. Entries that are URLs are left to pip.
+func localFindLinks(workingDir string, findLinks []string) []string {
+	var dirs []string
+	for _, dir := range findLinks {
+		dir = strings.TrimPrefix(dir, "file://")
+		if strings.Contains(dir, "://") {
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workingDir, dir)
+		}
+		dirs = append(dirs, dir)
+	}
+
+	return dirs
+}
+
+// localSdists returns the source distributions in the given directories.
+// Directories that do not exist or cannot be read are left to pip.
+func localSdists(dirs []string) []string {
+	var sdists []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && isSdist(entry.Name()) {
+				sdists = append(sdists, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	return sdists
+}
+
+func isSdist(name string) bool {
+	for _, extension := range sdistExtensions {
+		if strings.HasSuffix(strings.ToLower(name), extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// builtWheels returns the directories of the cache layer that hold the wheels
+// built from the given sdists, to be used as find-links, so that sdists are
+// only compiled once. The wheels are kept in
+// cachePath/built-wheels/<python tag>/<sha256 of the sdist>, and the wheels
+// of sdists that are missing from the cache are built with `pip wheel`. An
+// sdist that fails to build is left for pip to build during installation,
+// which reports the failure. Cached wheels of sdists that are no longer
+// present are removed. The directories whose wheels were reused from the
+// cache are reported as well.
+func (p PipInstallProcess) builtWheels(ctx context.Context, workingDir, cachePath string, environment pep508.Environment, sdists, indexArgs, env []string) ([]string, map[string]bool, error) {
+	root := filepath.Join(cachePath, "built-wheels")
+	tag := pythonTag(environment)
+
+	var dirs []string
+	used := map[string]bool{}
+	reused := map[string]bool{}
+
+	for _, sdist := range sdists {
+		dir, cached, err := cachedWheelDir(cachePath, tag, sdist)
+		if err != nil {
+			return nil, nil, err
+		}
+		used[dir] = true
+
+		if cached {
+			p.logger.Subprocess("Using cached wheel for '%s' (%s)", filepath.Base(sdist), tag)
+			dirs = append(dirs, dir)
+			reused[dir] = true
+			continue
+		}
+
+		built, err := p.buildSdist(ctx, workingDir, sdist, dir, indexArgs, env)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if built {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	entries, err := filepath.Glob(filepath.Join(root, "*", "*"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		if used[entry] {
+			continue
+		}
+
+		err = os.RemoveAll(entry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to remove cached wheel: %w", err)
+		}
+	}
+
+	return dirs, reused, nil
+}
+
+// requestedSdists returns the sdists of the projects that the requirements in
+// lines request by name, without a direct reference.
+func requestedSdists(sdists []string, lines []RequirementLine) []string {
+	names := map[string]bool{}
+	for _, line := range lines {
+		requirement, err := pep508.ParseRequirement(line.Requirement)
+		if line.Requirement == "" || err != nil || requirement.URL != "" {
+			continue
+		}
+		names[NormalizeName(requirement.Name)] = true
+	}
+
+	var requested []string
+	for _, sdist := range sdists {
+		if names[NormalizeName(sdistName(sdist))] {
+			requested = append(requested, sdist)
+		}
+	}
+	return requested
+}
+
+// cachedWheelDir returns the directory of the cache layer that holds the
+// wheel built from the sdist for the python tag, and reports whether it holds
+// one.
+func cachedWheelDir(cachePath, tag, sdist string) (string, bool, error) {
+	sum, err := fileSHA256(sdist)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to hash sdist: %w", err)
+	}
+
+	dir := filepath.Join(cachePath, "built-wheels", tag, sum)
+
+	wheels, err := filepath.Glob(filepath.Join(dir, "*.whl"))
+	if err != nil {
+		return "", false, err
+	}
+
+	return dir, len(wheels) > 0, nil
+}
+
+// sdistName returns the project name of an sdist file, such as "markupsafe"
+// for "markupsafe-3.0.2.tar.gz".
+func sdistName(sdist string) string {
+	name := filepath.Base(sdist)
+	for _, extension := range sdistExtensions {
+		if strings.HasSuffix(strings.ToLower(name), extension) {
+			name = name[:len(name)-len(extension)]
+			break
+		}
+	}
+
+	if index := strings.LastIndex(name, "-"); index > 0 {
+		name = name[:index]
+	}
+
+	return name
+}
+
+// localWheelNames returns the normalized project names of the wheels in the
+// given directories that the interpreter described by the environment
+// accepts. When its accepted tags cannot be determined, see wheelTarget, the
+// wheels are counted by their name alone.
+func localWheelNames(dirs []string, environment pep508.Environment) map[string]bool {
+	target, known := wheelTarget(environment)
+
+	names := map[string]bool{}
+	for _, dir := range dirs {
+		wheels, _ := filepath.Glob(filepath.Join(dir, "*.whl"))
+		for _, path := range wheels {
+			name, _, _ := strings.Cut(filepath.Base(path), "-")
+
+			if known {
+				wheel, err := pep425.ParseWheel(filepath.Base(path))
+				if err != nil {
+					continue
+				}
+
+				_, supported, err := target.Supports(wheel.Tags)
+				if err == nil && !supported {
+					continue
+				}
+			}
+
+			names[NormalizeName(name)] = true
+		}
+	}
+	return names
+}
+
+// wheelTarget returns the target whose accepted tags the local wheels are
+// checked against, and reports whether it is known. It is only known for
+// CPython on Linux, when the distribution of the build target, given in
+// CNB_TARGET_DISTRO_NAME and CNB_TARGET_DISTRO_VERSION, ships a known glibc.
+func wheelTarget(environment pep508.Environment) (pep425.Target, bool) {
+	if !strings.EqualFold(environment.ImplementationName, "cpython") || environment.SysPlatform != "linux" {
+		return pep425.Target{}, false
+	}
+
+	glibc, ok := pep425.GlibcVersion(os.Getenv("CNB_TARGET_DISTRO_NAME"), os.Getenv("CNB_TARGET_DISTRO_VERSION"))
+	if !ok {
+		return pep425.Target{}, false
+	}
+
+	return pep425.Target{
+		PythonVersion: environment.PythonVersion,
+		Arch:          environment.PlatformMachine,
+		Glibc:         glibc,
+	}, true
+}
+
+// buildSdist builds a wheel from the sdist into dir and reports whether it
+// succeeded. The wheel is built in a temporary directory first, so that a
+// failed build leaves no entry behind.
+func (p PipInstallProcess) buildSdist(ctx context.Context, workingDir, sdist, dir string, indexArgs, env []string) (bool, error) {
+	err := os.MkdirAll(filepath.Dir(dir), os.ModePerm)
+	if err != nil {
+		return false, fmt.Errorf("failed to create wheel cache: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), "build")
+	if err != nil {
+		return false, fmt.Errorf("failed to create wheel cache: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	args := append([]string{
+		"wheel",
+		"--no-deps",
+		fmt.Sprintf("--wheel-dir=%s", tmpDir),
+	}, indexArgs...)
+	args = append(args, "--disable-pip-version-check", sdist)
+
+	p.logger.Subprocess("Building a wheel from '%s'", filepath.Base(sdist))
+	p.logger.Subprocess("Running 'pip %s'", strings.Join(args, " "))
+
+	err = p.executable.Execute(pexec.Execution{
+		Args:   args,
+		Env:    env,
+		Dir:    workingDir,
+		Stdout: p.logger.ActionWriter,
+		Stderr: p.logger.ActionWriter,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, interrupted(ctx, fmt.Sprintf("building a wheel from '%s'", filepath.Base(sdist)), err)
+		}
+
+		p.logger.Subprocess("Warning: failed to build a wheel from '%s', leaving it to pip: %s", filepath.Base(sdist), err)
+		return false, nil
+	}
+
+	wheels, err := filepath.Glob(filepath.Join(tmpDir, "*.whl"))
+	if err != nil {
+		return false, err
+	}
+	if len(wheels) != 1 {
+		p.logger.Subprocess("Warning: failed to build a wheel from '%s', leaving it to pip: expected a single wheel, found %d", filepath.Base(sdist), len(wheels))
+		return false, nil
+	}
+
+	// A directory left without a wheel, e.g. by an interrupted build, would
+	// make the rename fail.
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to store built wheel: %w", err)
+	}
+
+	err = os.Rename(tmpDir, dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to store built wheel: %w", err)
+	}
+
+	return true, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}